@@ -0,0 +1,600 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// selectorAnnotation keeps the v1beta1 selector of a RollingUpdate read as v1alpha1 when the
+// selector cannot be expressed with MatchLabels, so it survives a round trip through v1alpha1.
+const selectorAnnotation = "flipper.example.com/v1beta1-selector"
+
+// durationsAnnotation keeps the v1beta1 durations of a RollingUpdate read as v1alpha1 that are
+// rounded to fit the units of v1alpha1, keyed by field, so they survive a round trip through
+// v1alpha1.
+const durationsAnnotation = "flipper.example.com/v1beta1-durations"
+
+// durationUnit is a unit of the v1alpha1 duration fields.
+type durationUnit struct {
+	suffix string
+	size   time.Duration
+}
+
+var (
+	// intervalUnits are the units the v1alpha1 interval is written with. It also accepts days
+	// and weeks, but hours are kept for durations of whole days as the previous versions did.
+	intervalUnits = []durationUnit{{"m", time.Minute}, {"h", time.Hour}}
+
+	// durationUnits are the units of the other v1alpha1 durations.
+	durationUnits = []durationUnit{{"s", time.Second}, {"m", time.Minute}, {"h", time.Hour}}
+
+	// verificationTimeoutUnits are the units of the v1alpha1 verification timeout.
+	verificationTimeoutUnits = []durationUnit{{"ms", time.Millisecond}, {"s", time.Second}, {"m", time.Minute}}
+)
+
+// unitlessDurationUnit is the unit of v1alpha1 intervals given as a plain number, such as "30".
+const unitlessDurationUnit = time.Minute
+
+// ConvertTo converts this RollingUpdate to the hub version (v1beta1).
+func (src *RollingUpdate) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.RollingUpdate)
+
+	dst.ObjectMeta = src.ObjectMeta
+	selector, err := selectorTo(src)
+	if err != nil {
+		return err
+	}
+	durations, err := exactDurationsOf(src)
+	if err != nil {
+		return err
+	}
+	for _, annotation := range []string{selectorAnnotation, durationsAnnotation} {
+		if _, ok := dst.Annotations[annotation]; ok {
+			dst.Annotations = maps.Clone(dst.Annotations)
+			delete(dst.Annotations, annotation)
+		}
+	}
+
+	interval, err := durations.parse("interval", src.Spec.Interval, intervalUnits)
+	if err != nil {
+		return err
+	}
+	startingDeadline, err := durations.parse("startingDeadline", src.Spec.StartingDeadline, durationUnits)
+	if err != nil {
+		return err
+	}
+	dst.Spec = v1beta1.RollingUpdateSpec{
+		Selector:           selector,
+		Suspend:            src.Spec.Suspend,
+		ServiceAccountName: src.Spec.ServiceAccountName,
+		Schedule: v1beta1.ScheduleSpec{
+			Interval:             interval,
+			AnchorTime:           src.Spec.AnchorTime,
+			MissedSchedulePolicy: v1beta1.MissedSchedulePolicy(src.Spec.MissedSchedulePolicy),
+			StartingDeadline:     startingDeadline,
+		},
+		OnFailure:      v1beta1.FailurePolicy(src.Spec.OnFailure),
+		OnRestartError: v1beta1.RestartErrorPolicy(src.Spec.OnRestartError),
+		RestartRetries: src.Spec.RestartRetries,
+		Method:         v1beta1.RestartMethod(src.Spec.Method),
+		MaxEvictedPods: src.Spec.MaxEvictedPods,
+	}
+	if preflight := src.Spec.Preflight; preflight != nil {
+		dst.Spec.Preflight = &v1beta1.PreflightSpec{
+			RequireDeploymentsAvailable: preflight.RequireDeploymentsAvailable,
+			RequireNoUnschedulablePods:  preflight.RequireNoUnschedulablePods,
+			MinReadyNodesPercent:        preflight.MinReadyNodesPercent,
+		}
+	}
+	if annotations := src.Spec.Annotations; annotations != nil {
+		dst.Spec.Annotations = &v1beta1.AnnotationSpec{
+			Placement: v1beta1.AnnotationPlacement(annotations.Placement),
+			Prefix:    annotations.Prefix,
+		}
+		for _, exclude := range annotations.Exclude {
+			dst.Spec.Annotations.Exclude = append(dst.Spec.Annotations.Exclude, v1beta1.RestartAnnotation(exclude))
+		}
+	}
+	for i, check := range src.Spec.Verification {
+		timeout, err := durations.parse(fmt.Sprintf("verification[%d].timeout", i), check.Timeout, verificationTimeoutUnits)
+		if err != nil {
+			return err
+		}
+		dst.Spec.Verification = append(dst.Spec.Verification, v1beta1.HTTPVerification{
+			Deployment:     check.Deployment,
+			Service:        check.Service,
+			Port:           check.Port,
+			Path:           check.Path,
+			ExpectedStatus: check.ExpectedStatus,
+			Timeout:        timeout,
+			Retries:        check.Retries,
+		})
+	}
+	if hooks := src.Spec.Hooks; hooks != nil {
+		dst.Spec.Hooks = &v1beta1.RestartHooks{}
+		if dst.Spec.Hooks.PreRestart, err = hookTo(hooks.PreRestart, "hooks.preRestart", durations); err != nil {
+			return err
+		}
+		if dst.Spec.Hooks.PostRestart, err = hookTo(hooks.PostRestart, "hooks.postRestart", durations); err != nil {
+			return err
+		}
+	}
+	if triggers := src.Spec.Triggers; triggers != nil {
+		dst.Spec.Triggers = &v1beta1.RestartTriggers{AutoDiscover: triggers.AutoDiscover}
+		for _, selector := range triggers.Secrets {
+			dst.Spec.Triggers.Secrets = append(dst.Spec.Triggers.Secrets, v1beta1.ObjectSelector(selector))
+		}
+		for _, selector := range triggers.ConfigMaps {
+			dst.Spec.Triggers.ConfigMaps = append(dst.Spec.Triggers.ConfigMaps, v1beta1.ObjectSelector(selector))
+		}
+	}
+	if thresholds := src.Spec.Thresholds; thresholds != nil {
+		maxPodAge, err := durations.parse("thresholds.maxPodAge", thresholds.MaxPodAge, durationUnits)
+		if err != nil {
+			return err
+		}
+		dst.Spec.Thresholds = &v1beta1.RestartThresholds{
+			MaxPodAge:            maxPodAge,
+			MaxContainerRestarts: thresholds.MaxContainerRestarts,
+		}
+	}
+	if metrics := src.Spec.Metrics; metrics != nil {
+		cooldown, err := durations.parse("metrics.cooldown", metrics.Cooldown, durationUnits)
+		if err != nil {
+			return err
+		}
+		dst.Spec.Metrics = &v1beta1.MetricsTrigger{
+			URL:      metrics.URL,
+			Query:    metrics.Query,
+			Cooldown: cooldown,
+		}
+	}
+
+	dst.Status = v1beta1.RollingUpdateStatus{
+		LastRolloutTime:       src.Status.LastRolloutTime,
+		NextRolloutTime:       src.Status.NextRolloutTime,
+		TargetCount:           src.Status.TargetCount,
+		CycleID:               src.Status.CycleID,
+		Targets:               targetsTo(src.Status.Deployments),
+		PreRestartHook:        hookStatusTo(src.Status.PreRestartHook),
+		PostRestartHook:       hookStatusTo(src.Status.PostRestartHook),
+		TriggerHashes:         src.Status.TriggerHashes,
+		WorkloadTriggerHashes: src.Status.WorkloadTriggerHashes,
+		MetricsTriggeredAt:    src.Status.MetricsTriggeredAt,
+		LastTrigger:           src.Status.LastTrigger,
+		Conditions:            src.Status.Conditions,
+	}
+	for _, entry := range src.Status.Deferred {
+		dst.Status.Deferred = append(dst.Status.Deferred, v1beta1.DeferredTarget{
+			TargetReference:     deploymentReference(entry.Name),
+			PodDisruptionBudget: entry.PodDisruptionBudget,
+		})
+	}
+	for _, workload := range src.Status.Workloads {
+		dst.Status.Workloads = append(dst.Status.Workloads, v1beta1.WorkloadStatus{
+			TargetReference:      deploymentReference(workload.Name),
+			Phase:                v1beta1.WorkloadPhase(workload.Phase),
+			RestartedAt:          workload.RestartedAt,
+			PreviousAnnotations:  workload.PreviousAnnotations,
+			Result:               v1beta1.RestartResult(workload.Result),
+			RestartAttempts:      workload.RestartAttempts,
+			VerificationAttempts: workload.VerificationAttempts,
+			PendingEvictions:     workload.PendingEvictions,
+			EvictedPod:           workload.EvictedPod,
+			HookJob:              workload.HookJob,
+			Message:              workload.Message,
+		})
+	}
+	for _, annotated := range src.Status.AnnotatedDeployments {
+		dst.Status.AnnotatedTargets = append(dst.Status.AnnotatedTargets, v1beta1.AnnotatedTarget{
+			TargetReference: deploymentReference(annotated.Name),
+			Annotations:     annotated.Annotations,
+		})
+	}
+	if missed := src.Status.MissedSchedule; missed != nil {
+		dst.Status.MissedSchedule = &v1beta1.MissedSchedule{
+			ScheduledTime: missed.ScheduledTime,
+			DetectedTime:  missed.DetectedTime,
+			Decision:      v1beta1.MissedScheduleDecision(missed.Decision),
+			NextTime:      missed.NextTime,
+			Message:       missed.Message,
+		}
+	}
+	for _, record := range src.Status.History {
+		dst.Status.History = append(dst.Status.History, v1beta1.RolloutRecord{
+			CycleID:        record.CycleID,
+			StartTime:      record.StartTime,
+			CompletionTime: record.CompletionTime,
+			Reason:         record.Reason,
+			Targets:        targetsTo(record.Deployments),
+			Result:         v1beta1.RolloutResult(record.Result),
+		})
+	}
+	return nil
+}
+
+// ConvertFrom converts from the hub version (v1beta1) to this version.
+func (dst *RollingUpdate) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.RollingUpdate)
+
+	dst.ObjectMeta = src.ObjectMeta
+	if selector := src.Spec.Selector; selector != nil && len(selector.MatchExpressions) > 0 {
+		data, err := json.Marshal(selector)
+		if err != nil {
+			return fmt.Errorf("failed to encode selector: %v", err)
+		}
+		dst.Annotations = maps.Clone(dst.Annotations)
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[selectorAnnotation] = string(data)
+	}
+
+	durations := exactDurations{}
+	dst.Spec = RollingUpdateSpec{
+		Suspend:              src.Spec.Suspend,
+		ServiceAccountName:   src.Spec.ServiceAccountName,
+		Interval:             durations.format("interval", src.Spec.Schedule.Interval, intervalUnits),
+		AnchorTime:           src.Spec.Schedule.AnchorTime,
+		MissedSchedulePolicy: MissedSchedulePolicy(src.Spec.Schedule.MissedSchedulePolicy),
+		StartingDeadline:     durations.format("startingDeadline", src.Spec.Schedule.StartingDeadline, durationUnits),
+		OnFailure:            FailurePolicy(src.Spec.OnFailure),
+		OnRestartError:       RestartErrorPolicy(src.Spec.OnRestartError),
+		RestartRetries:       src.Spec.RestartRetries,
+		Method:               RestartMethod(src.Spec.Method),
+		MaxEvictedPods:       src.Spec.MaxEvictedPods,
+	}
+	if src.Spec.Selector != nil {
+		dst.Spec.MatchLabels = src.Spec.Selector.MatchLabels
+	}
+	if preflight := src.Spec.Preflight; preflight != nil {
+		dst.Spec.Preflight = &PreflightSpec{
+			RequireDeploymentsAvailable: preflight.RequireDeploymentsAvailable,
+			RequireNoUnschedulablePods:  preflight.RequireNoUnschedulablePods,
+			MinReadyNodesPercent:        preflight.MinReadyNodesPercent,
+		}
+	}
+	if annotations := src.Spec.Annotations; annotations != nil {
+		dst.Spec.Annotations = &AnnotationSpec{
+			Placement: AnnotationPlacement(annotations.Placement),
+			Prefix:    annotations.Prefix,
+		}
+		for _, exclude := range annotations.Exclude {
+			dst.Spec.Annotations.Exclude = append(dst.Spec.Annotations.Exclude, RestartAnnotation(exclude))
+		}
+	}
+	for i, check := range src.Spec.Verification {
+		dst.Spec.Verification = append(dst.Spec.Verification, HTTPVerification{
+			Deployment:     check.Deployment,
+			Service:        check.Service,
+			Port:           check.Port,
+			Path:           check.Path,
+			ExpectedStatus: check.ExpectedStatus,
+			Timeout:        durations.format(fmt.Sprintf("verification[%d].timeout", i), check.Timeout, verificationTimeoutUnits),
+			Retries:        check.Retries,
+		})
+	}
+	if hooks := src.Spec.Hooks; hooks != nil {
+		dst.Spec.Hooks = &RestartHooks{
+			PreRestart:  hookFrom(hooks.PreRestart, "hooks.preRestart", durations),
+			PostRestart: hookFrom(hooks.PostRestart, "hooks.postRestart", durations),
+		}
+	}
+	if triggers := src.Spec.Triggers; triggers != nil {
+		dst.Spec.Triggers = &RestartTriggers{AutoDiscover: triggers.AutoDiscover}
+		for _, selector := range triggers.Secrets {
+			dst.Spec.Triggers.Secrets = append(dst.Spec.Triggers.Secrets, ObjectSelector(selector))
+		}
+		for _, selector := range triggers.ConfigMaps {
+			dst.Spec.Triggers.ConfigMaps = append(dst.Spec.Triggers.ConfigMaps, ObjectSelector(selector))
+		}
+	}
+	if thresholds := src.Spec.Thresholds; thresholds != nil {
+		dst.Spec.Thresholds = &RestartThresholds{
+			MaxPodAge:            durations.format("thresholds.maxPodAge", thresholds.MaxPodAge, durationUnits),
+			MaxContainerRestarts: thresholds.MaxContainerRestarts,
+		}
+	}
+	if metrics := src.Spec.Metrics; metrics != nil {
+		dst.Spec.Metrics = &MetricsTrigger{
+			URL:      metrics.URL,
+			Query:    metrics.Query,
+			Cooldown: durations.format("metrics.cooldown", metrics.Cooldown, durationUnits),
+		}
+	}
+	if err := durations.annotate(dst); err != nil {
+		return err
+	}
+
+	dst.Status = RollingUpdateStatus{
+		LastRolloutTime:       src.Status.LastRolloutTime,
+		NextRolloutTime:       src.Status.NextRolloutTime,
+		TargetCount:           src.Status.TargetCount,
+		CycleID:               src.Status.CycleID,
+		Deployments:           targetsFrom(src.Status.Targets),
+		PreRestartHook:        hookStatusFrom(src.Status.PreRestartHook),
+		PostRestartHook:       hookStatusFrom(src.Status.PostRestartHook),
+		TriggerHashes:         src.Status.TriggerHashes,
+		WorkloadTriggerHashes: src.Status.WorkloadTriggerHashes,
+		MetricsTriggeredAt:    src.Status.MetricsTriggeredAt,
+		LastTrigger:           src.Status.LastTrigger,
+		Conditions:            src.Status.Conditions,
+	}
+	for _, entry := range src.Status.Deferred {
+		dst.Status.Deferred = append(dst.Status.Deferred, DeferredDeployment{
+			Name:                entry.Name,
+			PodDisruptionBudget: entry.PodDisruptionBudget,
+		})
+	}
+	for _, workload := range src.Status.Workloads {
+		dst.Status.Workloads = append(dst.Status.Workloads, WorkloadStatus{
+			Name:                 workload.Name,
+			Phase:                WorkloadPhase(workload.Phase),
+			RestartedAt:          workload.RestartedAt,
+			PreviousAnnotations:  workload.PreviousAnnotations,
+			Result:               RestartResult(workload.Result),
+			RestartAttempts:      workload.RestartAttempts,
+			VerificationAttempts: workload.VerificationAttempts,
+			PendingEvictions:     workload.PendingEvictions,
+			EvictedPod:           workload.EvictedPod,
+			HookJob:              workload.HookJob,
+			Message:              workload.Message,
+		})
+	}
+	for _, annotated := range src.Status.AnnotatedTargets {
+		dst.Status.AnnotatedDeployments = append(dst.Status.AnnotatedDeployments, AnnotatedDeployment{
+			Name:        annotated.Name,
+			Annotations: annotated.Annotations,
+		})
+	}
+	if missed := src.Status.MissedSchedule; missed != nil {
+		dst.Status.MissedSchedule = &MissedSchedule{
+			ScheduledTime: missed.ScheduledTime,
+			DetectedTime:  missed.DetectedTime,
+			Decision:      MissedScheduleDecision(missed.Decision),
+			NextTime:      missed.NextTime,
+			Message:       missed.Message,
+		}
+	}
+	for _, record := range src.Status.History {
+		dst.Status.History = append(dst.Status.History, RolloutRecord{
+			CycleID:        record.CycleID,
+			StartTime:      record.StartTime,
+			CompletionTime: record.CompletionTime,
+			Reason:         record.Reason,
+			Deployments:    targetsFrom(record.Targets),
+			Result:         RolloutResult(record.Result),
+		})
+	}
+	return nil
+}
+
+// selectorTo returns the v1beta1 selector of src. The selector kept in the selectorAnnotation
+// is used if its MatchLabels still equal the MatchLabels of src.
+func selectorTo(src *RollingUpdate) (*metav1.LabelSelector, error) {
+	if data, ok := src.Annotations[selectorAnnotation]; ok {
+		selector := &metav1.LabelSelector{}
+		if err := json.Unmarshal([]byte(data), selector); err != nil {
+			return nil, fmt.Errorf("failed to decode the %s annotation: %v", selectorAnnotation, err)
+		}
+		if maps.Equal(selector.MatchLabels, src.Spec.MatchLabels) {
+			return selector, nil
+		}
+	}
+	if len(src.Spec.MatchLabels) == 0 {
+		return nil, nil
+	}
+	return &metav1.LabelSelector{MatchLabels: src.Spec.MatchLabels}, nil
+}
+
+func hookTo(hook *HookSpec, field string, durations exactDurations) (*v1beta1.HookSpec, error) {
+	if hook == nil {
+		return nil, nil
+	}
+	timeout, err := durations.parse(field+".timeout", hook.Timeout, durationUnits)
+	if err != nil {
+		return nil, err
+	}
+	return &v1beta1.HookSpec{
+		Scope:    v1beta1.HookScope(hook.Scope),
+		Timeout:  timeout,
+		Template: hook.Template,
+	}, nil
+}
+
+func hookFrom(hook *v1beta1.HookSpec, field string, durations exactDurations) *HookSpec {
+	if hook == nil {
+		return nil
+	}
+	return &HookSpec{
+		Scope:    HookScope(hook.Scope),
+		Timeout:  durations.format(field+".timeout", hook.Timeout, durationUnits),
+		Template: hook.Template,
+	}
+}
+
+func hookStatusTo(hook *HookStatus) *v1beta1.HookStatus {
+	if hook == nil {
+		return nil
+	}
+	return &v1beta1.HookStatus{
+		JobName: hook.JobName,
+		Phase:   v1beta1.HookPhase(hook.Phase),
+		Message: hook.Message,
+	}
+}
+
+func hookStatusFrom(hook *v1beta1.HookStatus) *HookStatus {
+	if hook == nil {
+		return nil
+	}
+	return &HookStatus{
+		JobName: hook.JobName,
+		Phase:   HookPhase(hook.Phase),
+		Message: hook.Message,
+	}
+}
+
+func deploymentReference(name string) v1beta1.TargetReference {
+	return v1beta1.TargetReference{Kind: v1beta1.TargetKindDeployment, Name: name}
+}
+
+func targetsTo(names []string) []v1beta1.TargetReference {
+	if names == nil {
+		return nil
+	}
+	targets := []v1beta1.TargetReference{}
+	for _, name := range names {
+		targets = append(targets, deploymentReference(name))
+	}
+	return targets
+}
+
+func targetsFrom(targets []v1beta1.TargetReference) []string {
+	if targets == nil {
+		return nil
+	}
+	names := []string{}
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	return names
+}
+
+// exactDurations holds the v1beta1 durations of a RollingUpdate that v1alpha1 cannot express,
+// keyed by field.
+type exactDurations map[string]string
+
+// exactDurationsOf returns the durations kept in the durationsAnnotation of src.
+func exactDurationsOf(src *RollingUpdate) (exactDurations, error) {
+	durations := exactDurations{}
+	if data, ok := src.Annotations[durationsAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), &durations); err != nil {
+			return nil, fmt.Errorf("failed to decode the %s annotation: %v", durationsAnnotation, err)
+		}
+	}
+	return durations, nil
+}
+
+// parse parses value, the v1alpha1 form of field. The exact duration kept for field is returned
+// instead if it is still formatted as value.
+func (d exactDurations) parse(field, value string, units []durationUnit) (*metav1.Duration, error) {
+	duration, err := parseDuration(field, value)
+	if err != nil || duration == nil {
+		return duration, err
+	}
+	if exact, ok := d[field]; ok {
+		if kept, err := time.ParseDuration(exact); err == nil {
+			if formatted, _ := formatDuration(&metav1.Duration{Duration: kept}, units); formatted == value {
+				return &metav1.Duration{Duration: kept}, nil
+			}
+		}
+	}
+	return duration, nil
+}
+
+// format formats duration, the v1beta1 form of field, with units. Durations that are rounded
+// are kept for field.
+func (d exactDurations) format(field string, duration *metav1.Duration, units []durationUnit) string {
+	formatted, exact := formatDuration(duration, units)
+	if !exact {
+		d[field] = duration.Duration.String()
+	}
+	return formatted
+}
+
+// annotate records the kept durations in the durationsAnnotation of dst, replacing the durations
+// recorded before.
+func (d exactDurations) annotate(dst *RollingUpdate) error {
+	if _, ok := dst.Annotations[durationsAnnotation]; !ok && len(d) == 0 {
+		return nil
+	}
+	dst.Annotations = maps.Clone(dst.Annotations)
+	delete(dst.Annotations, durationsAnnotation)
+	if len(d) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to encode durations: %v", err)
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = map[string]string{}
+	}
+	dst.Annotations[durationsAnnotation] = string(data)
+	return nil
+}
+
+// parseDuration parses a v1alpha1 duration string. Besides the units of time.ParseDuration,
+// the d (day) and w (week) units accepted by the Interval validation are supported, and plain
+// numbers are read as minutes.
+func parseDuration(field, value string) (*metav1.Duration, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if count, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return &metav1.Duration{Duration: time.Duration(count) * unitlessDurationUnit}, nil
+	}
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit != 0 {
+		count, err := strconv.ParseInt(strings.TrimSuffix(value, value[len(value)-1:]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", field, value, err)
+		}
+		return &metav1.Duration{Duration: time.Duration(count) * unit}, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %v", field, value, err)
+	}
+	return &metav1.Duration{Duration: duration}, nil
+}
+
+// formatDuration formats duration in the single unit form used by v1alpha1, such as "24h", with
+// the largest of units it is a whole number of. Durations that are not a whole number of the
+// smallest unit are rounded up to one, so intervals and timeouts are never shortened. It reports
+// whether duration was formatted exactly.
+func formatDuration(duration *metav1.Duration, units []durationUnit) (string, bool) {
+	if duration == nil {
+		return "", true
+	}
+	for i := len(units) - 1; i >= 0; i-- {
+		if duration.Duration%units[i].size == 0 {
+			return fmt.Sprintf("%d%s", duration.Duration/units[i].size, units[i].suffix), true
+		}
+	}
+	smallest := units[0]
+	count := (duration.Duration + smallest.size - 1) / smallest.size
+	return fmt.Sprintf("%d%s", count, smallest.suffix), false
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+var _ = Describe("RollingUpdate conversion", func() {
+	newRollingUpdate := func() *RollingUpdate {
+		anchorTime := metav1.NewTime(time.Date(2024, 6, 18, 12, 0, 0, 0, time.UTC))
+		retries := int32(2)
+		return &RollingUpdate{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+			Spec: RollingUpdateSpec{
+				MatchLabels:          map[string]string{"app": "nginx"},
+				Interval:             "12h",
+				ServiceAccountName:   "nginx-restarter",
+				AnchorTime:           &anchorTime,
+				MissedSchedulePolicy: MissedSchedulePolicySkip,
+				StartingDeadline:     "30m",
+				OnFailure:            FailurePolicyRollback,
+				RestartRetries:       &retries,
+				Annotations: &AnnotationSpec{
+					Placement: AnnotationPlacementTemplateAndObject,
+					Exclude:   []RestartAnnotation{RestartAnnotationRestartedBy},
+				},
+				Verification: []HTTPVerification{{Service: "nginx", Port: 80, Path: "/", ExpectedStatus: 200, Timeout: "500ms", Retries: 3}},
+				Hooks: &RestartHooks{
+					PreRestart: &HookSpec{Scope: HookScopeCycle, Timeout: "10m", Template: batchv1.JobTemplateSpec{}},
+				},
+				Triggers:   &RestartTriggers{ConfigMaps: []ObjectSelector{{Name: "nginx-config"}}},
+				Thresholds: &RestartThresholds{MaxPodAge: "168h"},
+				Metrics:    &MetricsTrigger{URL: "http://prometheus:9090", Query: "up == 0", Cooldown: "1h"},
+			},
+			Status: RollingUpdateStatus{
+				CycleID:     "2024-06-18T12:00:00Z",
+				Deployments: []string{"nginx", "nginx-canary"},
+				Deferred:    []DeferredDeployment{{Name: "nginx-canary", PodDisruptionBudget: "nginx"}},
+				Workloads: []WorkloadStatus{
+					{Name: "nginx", Phase: WorkloadPhaseRestarting, RestartedAt: "2024-06-18T12:00:00Z"},
+				},
+				AnnotatedDeployments: []AnnotatedDeployment{
+					{Name: "nginx", Annotations: []string{"flipper.example.com/restartedByCRDKind", "kubectl.kubernetes.io/restartedAt"}},
+				},
+				PreRestartHook: &HookStatus{JobName: "nginx-pre-restart", Phase: HookPhaseSucceeded},
+				History: []RolloutRecord{
+					{CycleID: "2024-06-18T12:00:00Z", Reason: "Scheduled", Deployments: []string{"nginx"}},
+				},
+			},
+		}
+	}
+
+	It("should convert a v1alpha1 RollingUpdate to v1beta1 and back without changes", func() {
+		original := newRollingUpdate()
+
+		hub := &v1beta1.RollingUpdate{}
+		Expect(original.DeepCopy().ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Selector).To(Equal(&metav1.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}}))
+		Expect(hub.Spec.Schedule.Interval.Duration).To(Equal(12 * time.Hour))
+		Expect(hub.Spec.Schedule.StartingDeadline.Duration).To(Equal(30 * time.Minute))
+		Expect(hub.Spec.Verification[0].Timeout.Duration).To(Equal(500 * time.Millisecond))
+		Expect(hub.Status.Targets).To(Equal([]v1beta1.TargetReference{
+			{Kind: v1beta1.TargetKindDeployment, Name: "nginx"},
+			{Kind: v1beta1.TargetKindDeployment, Name: "nginx-canary"},
+		}))
+		Expect(hub.Status.Workloads[0].TargetReference).To(Equal(v1beta1.TargetReference{Kind: v1beta1.TargetKindDeployment, Name: "nginx"}))
+		Expect(hub.Status.AnnotatedTargets[0].TargetReference).To(Equal(v1beta1.TargetReference{Kind: v1beta1.TargetKindDeployment, Name: "nginx"}))
+
+		converted := &RollingUpdate{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted).To(Equal(original))
+	})
+
+	It("should convert intervals in days and weeks", func() {
+		rollingUpdate := newRollingUpdate()
+		rollingUpdate.Spec.Interval = "2w"
+
+		hub := &v1beta1.RollingUpdate{}
+		Expect(rollingUpdate.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Schedule.Interval.Duration).To(Equal(14 * 24 * time.Hour))
+
+		rollingUpdate.Spec.Interval = "3x"
+		Expect(rollingUpdate.ConvertTo(hub)).NotTo(Succeed())
+	})
+
+	It("should read intervals without a unit as minutes", func() {
+		rollingUpdate := newRollingUpdate()
+		rollingUpdate.Spec.Interval = "30"
+
+		hub := &v1beta1.RollingUpdate{}
+		Expect(rollingUpdate.DeepCopy().ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Schedule.Interval.Duration).To(Equal(30 * time.Minute))
+
+		converted := &RollingUpdate{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted.Spec.Interval).To(Equal("30m"))
+		rollingUpdate.Spec.Interval = "30m"
+		Expect(converted).To(Equal(rollingUpdate))
+	})
+
+	It("should round durations v1alpha1 cannot express and keep them exactly", func() {
+		hub := &v1beta1.RollingUpdate{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: v1beta1.RollingUpdateSpec{
+				Schedule: v1beta1.ScheduleSpec{
+					Interval:         &metav1.Duration{Duration: 90 * time.Second},
+					StartingDeadline: &metav1.Duration{Duration: 1500 * time.Millisecond},
+				},
+				Verification: []v1beta1.HTTPVerification{{Service: "web", Port: 80, Timeout: &metav1.Duration{Duration: 2500 * time.Microsecond}}},
+				Hooks: &v1beta1.RestartHooks{
+					PostRestart: &v1beta1.HookSpec{Timeout: &metav1.Duration{Duration: 10 * time.Minute}},
+				},
+				Metrics: &v1beta1.MetricsTrigger{URL: "http://prometheus:9090", Cooldown: &metav1.Duration{Duration: 90 * time.Minute}},
+			},
+		}
+
+		rollingUpdate := &RollingUpdate{}
+		Expect(rollingUpdate.ConvertFrom(hub)).To(Succeed())
+		Expect(rollingUpdate.Spec.Interval).To(Equal("2m"))
+		Expect(rollingUpdate.Spec.Interval).To(MatchRegexp(`^[0-9]+(m|h|d|w)?$`))
+		Expect(rollingUpdate.Spec.StartingDeadline).To(Equal("2s"))
+		Expect(rollingUpdate.Spec.StartingDeadline).To(MatchRegexp(`^[0-9]+(s|m|h)$`))
+		Expect(rollingUpdate.Spec.Verification[0].Timeout).To(Equal("3ms"))
+		Expect(rollingUpdate.Spec.Verification[0].Timeout).To(MatchRegexp(`^[0-9]+(ms|s|m)$`))
+		Expect(rollingUpdate.Spec.Hooks.PostRestart.Timeout).To(Equal("10m"))
+		Expect(rollingUpdate.Spec.Metrics.Cooldown).To(Equal("90m"))
+		Expect(rollingUpdate.Annotations).To(HaveKeyWithValue(durationsAnnotation,
+			`{"interval":"1m30s","startingDeadline":"1.5s","verification[0].timeout":"2.5ms"}`))
+		Expect(hub.Annotations).NotTo(HaveKey(durationsAnnotation))
+
+		converted := &v1beta1.RollingUpdate{}
+		Expect(rollingUpdate.ConvertTo(converted)).To(Succeed())
+		Expect(converted.Spec).To(Equal(hub.Spec))
+		Expect(converted.Annotations).NotTo(HaveKey(durationsAnnotation))
+
+		By("Dropping the kept duration once the v1alpha1 value changed")
+		rollingUpdate.Spec.Interval = "5m"
+		Expect(rollingUpdate.ConvertTo(converted)).To(Succeed())
+		Expect(converted.Spec.Schedule.Interval.Duration).To(Equal(5 * time.Minute))
+		Expect(converted.Spec.Schedule.StartingDeadline.Duration).To(Equal(1500 * time.Millisecond))
+
+		By("Dropping the kept durations once they are expressed exactly")
+		converted.Annotations = rollingUpdate.Annotations
+		Expect(rollingUpdate.ConvertFrom(converted)).To(Succeed())
+		Expect(rollingUpdate.Annotations).To(HaveKeyWithValue(durationsAnnotation,
+			`{"startingDeadline":"1.5s","verification[0].timeout":"2.5ms"}`))
+	})
+
+	It("should keep selectors that cannot be expressed with match labels", func() {
+		selector := &metav1.LabelSelector{
+			MatchLabels: map[string]string{"tier": "web"},
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"nginx", "httpd"}},
+			},
+		}
+		hub := &v1beta1.RollingUpdate{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: v1beta1.RollingUpdateSpec{
+				Selector: selector,
+				Schedule: v1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: 90 * time.Minute}},
+			},
+		}
+
+		rollingUpdate := &RollingUpdate{}
+		Expect(rollingUpdate.ConvertFrom(hub)).To(Succeed())
+		Expect(rollingUpdate.Spec.MatchLabels).To(Equal(map[string]string{"tier": "web"}))
+		Expect(rollingUpdate.Spec.Interval).To(Equal("90m"))
+		Expect(rollingUpdate.Annotations).To(HaveKey(selectorAnnotation))
+		Expect(hub.Annotations).NotTo(HaveKey(selectorAnnotation))
+
+		converted := &v1beta1.RollingUpdate{}
+		Expect(rollingUpdate.ConvertTo(converted)).To(Succeed())
+		Expect(converted.Spec.Selector).To(Equal(selector))
+		Expect(converted.Annotations).NotTo(HaveKey(selectorAnnotation))
+
+		By("Dropping the kept selector once the match labels changed")
+		rollingUpdate.Spec.MatchLabels = map[string]string{"tier": "api"}
+		Expect(rollingUpdate.ConvertTo(converted)).To(Succeed())
+		Expect(converted.Spec.Selector).To(Equal(&metav1.LabelSelector{MatchLabels: map[string]string{"tier": "api"}}))
+	})
+})
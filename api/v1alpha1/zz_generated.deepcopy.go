@@ -21,9 +21,263 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnnotatedDeployment) DeepCopyInto(out *AnnotatedDeployment) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnnotatedDeployment.
+func (in *AnnotatedDeployment) DeepCopy() *AnnotatedDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(AnnotatedDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnnotationSpec) DeepCopyInto(out *AnnotationSpec) {
+	*out = *in
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]RestartAnnotation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnnotationSpec.
+func (in *AnnotationSpec) DeepCopy() *AnnotationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AnnotationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeferredDeployment) DeepCopyInto(out *DeferredDeployment) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeferredDeployment.
+func (in *DeferredDeployment) DeepCopy() *DeferredDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(DeferredDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPVerification) DeepCopyInto(out *HTTPVerification) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPVerification.
+func (in *HTTPVerification) DeepCopy() *HTTPVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookSpec) DeepCopyInto(out *HookSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookSpec.
+func (in *HookSpec) DeepCopy() *HookSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HookSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookStatus) DeepCopyInto(out *HookStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookStatus.
+func (in *HookStatus) DeepCopy() *HookStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HookStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsTrigger) DeepCopyInto(out *MetricsTrigger) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsTrigger.
+func (in *MetricsTrigger) DeepCopy() *MetricsTrigger {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsTrigger)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MissedSchedule) DeepCopyInto(out *MissedSchedule) {
+	*out = *in
+	in.ScheduledTime.DeepCopyInto(&out.ScheduledTime)
+	in.DetectedTime.DeepCopyInto(&out.DetectedTime)
+	if in.NextTime != nil {
+		in, out := &in.NextTime, &out.NextTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissedSchedule.
+func (in *MissedSchedule) DeepCopy() *MissedSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(MissedSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectSelector) DeepCopyInto(out *ObjectSelector) {
+	*out = *in
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectSelector.
+func (in *ObjectSelector) DeepCopy() *ObjectSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightSpec) DeepCopyInto(out *PreflightSpec) {
+	*out = *in
+	if in.MinReadyNodesPercent != nil {
+		in, out := &in.MinReadyNodesPercent, &out.MinReadyNodesPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightSpec.
+func (in *PreflightSpec) DeepCopy() *PreflightSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestartHooks) DeepCopyInto(out *RestartHooks) {
+	*out = *in
+	if in.PreRestart != nil {
+		in, out := &in.PreRestart, &out.PreRestart
+		*out = new(HookSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostRestart != nil {
+		in, out := &in.PostRestart, &out.PostRestart
+		*out = new(HookSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestartHooks.
+func (in *RestartHooks) DeepCopy() *RestartHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(RestartHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestartThresholds) DeepCopyInto(out *RestartThresholds) {
+	*out = *in
+	if in.MaxContainerRestarts != nil {
+		in, out := &in.MaxContainerRestarts, &out.MaxContainerRestarts
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestartThresholds.
+func (in *RestartThresholds) DeepCopy() *RestartThresholds {
+	if in == nil {
+		return nil
+	}
+	out := new(RestartThresholds)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestartTriggers) DeepCopyInto(out *RestartTriggers) {
+	*out = *in
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]ObjectSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]ObjectSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestartTriggers.
+func (in *RestartTriggers) DeepCopy() *RestartTriggers {
+	if in == nil {
+		return nil
+	}
+	out := new(RestartTriggers)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RollingUpdate) DeepCopyInto(out *RollingUpdate) {
 	*out = *in
@@ -93,6 +347,55 @@ func (in *RollingUpdateSpec) DeepCopyInto(out *RollingUpdateSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.AnchorTime != nil {
+		in, out := &in.AnchorTime, &out.AnchorTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Preflight != nil {
+		in, out := &in.Preflight, &out.Preflight
+		*out = new(PreflightSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestartRetries != nil {
+		in, out := &in.RestartRetries, &out.RestartRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxEvictedPods != nil {
+		in, out := &in.MaxEvictedPods, &out.MaxEvictedPods
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = new(AnnotationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = make([]HTTPVerification, len(*in))
+		copy(*out, *in)
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(RestartHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Triggers != nil {
+		in, out := &in.Triggers, &out.Triggers
+		*out = new(RestartTriggers)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Thresholds != nil {
+		in, out := &in.Thresholds, &out.Thresholds
+		*out = new(RestartThresholds)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(MetricsTrigger)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateSpec.
@@ -109,11 +412,95 @@ func (in *RollingUpdateSpec) DeepCopy() *RollingUpdateSpec {
 func (in *RollingUpdateStatus) DeepCopyInto(out *RollingUpdateStatus) {
 	*out = *in
 	in.LastRolloutTime.DeepCopyInto(&out.LastRolloutTime)
+	if in.NextRolloutTime != nil {
+		in, out := &in.NextRolloutTime, &out.NextRolloutTime
+		*out = (*in).DeepCopy()
+	}
 	if in.Deployments != nil {
 		in, out := &in.Deployments, &out.Deployments
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Deferred != nil {
+		in, out := &in.Deferred, &out.Deferred
+		*out = make([]DeferredDeployment, len(*in))
+		copy(*out, *in)
+	}
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]WorkloadStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AnnotatedDeployments != nil {
+		in, out := &in.AnnotatedDeployments, &out.AnnotatedDeployments
+		*out = make([]AnnotatedDeployment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PreRestartHook != nil {
+		in, out := &in.PreRestartHook, &out.PreRestartHook
+		*out = new(HookStatus)
+		**out = **in
+	}
+	if in.PostRestartHook != nil {
+		in, out := &in.PostRestartHook, &out.PostRestartHook
+		*out = new(HookStatus)
+		**out = **in
+	}
+	if in.TriggerHashes != nil {
+		in, out := &in.TriggerHashes, &out.TriggerHashes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.WorkloadTriggerHashes != nil {
+		in, out := &in.WorkloadTriggerHashes, &out.WorkloadTriggerHashes
+		*out = make(map[string]map[string]string, len(*in))
+		for key, val := range *in {
+			var outVal map[string]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.MetricsTriggeredAt != nil {
+		in, out := &in.MetricsTriggeredAt, &out.MetricsTriggeredAt
+		*out = make(map[string]v1.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.MissedSchedule != nil {
+		in, out := &in.MissedSchedule, &out.MissedSchedule
+		*out = new(MissedSchedule)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]RolloutRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateStatus.
@@ -125,3 +512,55 @@ func (in *RollingUpdateStatus) DeepCopy() *RollingUpdateStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutRecord) DeepCopyInto(out *RolloutRecord) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Deployments != nil {
+		in, out := &in.Deployments, &out.Deployments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutRecord.
+func (in *RolloutRecord) DeepCopy() *RolloutRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadStatus) DeepCopyInto(out *WorkloadStatus) {
+	*out = *in
+	if in.PreviousAnnotations != nil {
+		in, out := &in.PreviousAnnotations, &out.PreviousAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PendingEvictions != nil {
+		in, out := &in.PendingEvictions, &out.PendingEvictions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadStatus.
+func (in *WorkloadStatus) DeepCopy() *WorkloadStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,853 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// RollingUpdateSpec defines the desired state of RollingUpdate
+type RollingUpdateSpec struct {
+	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// Selector selects the deployments in the namespace of the RollingUpdate that are
+	// restarted. If Selector is not specified, all deployments in the namespace are restarted.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Suspend stops the RollingUpdate from starting new rollouts. A rollout in progress when the
+	// RollingUpdate is suspended is completed.
+	// +kubebuilder:default=false
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// ServiceAccountName names a ServiceAccount in the namespace of the RollingUpdate. If set, the
+	// operator impersonates it to list, restart and roll back the targeted deployments and to
+	// evict their pods, so the RBAC of the ServiceAccount decides which deployments the
+	// RollingUpdate may restart. If not set, the permissions of the operator are used.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Schedule specifies when rollouts are due.
+	// +kubebuilder:default={}
+	// +optional
+	Schedule ScheduleSpec `json:"schedule,omitempty"`
+
+	// Preflight specifies the health and capacity checks that must pass before a rolling restart
+	// is started. If any of the enabled checks fails, the rollout is deferred and retried later,
+	// and the failure is reported through the PreflightFailed condition.
+	// If Preflight is not specified, no pre-flight checks are performed.
+	// +optional
+	Preflight *PreflightSpec `json:"preflight,omitempty"`
+
+	// OnFailure specifies how the operator reacts when a restarted deployment fails to roll out,
+	// either because the rollout exceeded its progress deadline or because the new pods are
+	// crash-looping. Valid values are:
+	// - "continue": record the failure and keep following the schedule;
+	// - "pause": do not start further rollouts until the failed deployments recover;
+	// - "rollback": revert the pod template annotations of the failed deployments to their
+	//   values before the restart and stop restarting the remaining deployments of the rollout.
+	// +optional
+	// +kubebuilder:validation:Enum=continue;pause;rollback
+	// +kubebuilder:default=continue
+	OnFailure FailurePolicy `json:"onFailure,omitempty"`
+
+	// OnRestartError specifies how the operator reacts when restarting a deployment fails, for
+	// example because the API server rejected the patch. Only the failed deployments are retried,
+	// up to RestartRetries times. Valid values are:
+	// - "continue": keep restarting the other deployments while the failed ones are retried,
+	//   and mark a deployment as failed once all its retries failed;
+	// - "abort": stop restarting further deployments while a failed one is retried, and skip
+	//   the deployments not restarted yet once all its retries failed.
+	// +optional
+	// +kubebuilder:validation:Enum=continue;abort
+	// +kubebuilder:default=continue
+	OnRestartError RestartErrorPolicy `json:"onRestartError,omitempty"`
+
+	// RestartRetries is the number of times a failed restart of a deployment is retried before
+	// the OnRestartError policy is applied. Defaults to 3.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RestartRetries *int32 `json:"restartRetries,omitempty"`
+
+	// Method selects how deployments are restarted:
+	// - "rolloutAnnotation": update the restart annotations of the pod template, which rolls
+	//   out a new ReplicaSet like "kubectl rollout restart".
+	// - "evictPods": evict the pods of the deployment one at a time through the Eviction API,
+	//   honoring PodDisruptionBudgets and waiting for each replacement to become ready. The pod
+	//   template is left unchanged.
+	// +optional
+	// +kubebuilder:validation:Enum=rolloutAnnotation;evictPods
+	// +kubebuilder:default=rolloutAnnotation
+	Method RestartMethod `json:"method,omitempty"`
+
+	// MaxEvictedPods limits the evictPods method to the given number of oldest pods of each
+	// deployment. If not set, all pods are evicted.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxEvictedPods *int32 `json:"maxEvictedPods,omitempty"`
+
+	// Annotations controls which restart annotations are written to the deployments and where.
+	// If not set, all restart annotations are written to the pod template only.
+	// +optional
+	Annotations *AnnotationSpec `json:"annotations,omitempty"`
+
+	// Verification lists HTTP checks that must pass after a restarted deployment completed its
+	// rollout. A deployment whose checks keep failing after all retries is considered failed,
+	// and the OnFailure policy is applied to it.
+	// +optional
+	Verification []HTTPVerification `json:"verification,omitempty"`
+
+	// Hooks specifies Jobs run before and after restarts, for example to drain queues or warm
+	// caches. A failed hook aborts the rollout.
+	// +optional
+	Hooks *RestartHooks `json:"hooks,omitempty"`
+
+	// Triggers lists Secrets and ConfigMaps whose changes start a rollout in addition to the
+	// Interval. A rollout is started when the data of a selected object changes; objects that
+	// start or stop being selected only update the recorded hashes.
+	// +optional
+	Triggers *RestartTriggers `json:"triggers,omitempty"`
+
+	// Thresholds switches the RollingUpdate to condition based restarts. If set, Interval no
+	// longer restarts the targets; instead their pods are inspected every five minutes and only
+	// the deployments exceeding a threshold are restarted.
+	// +optional
+	Thresholds *RestartThresholds `json:"thresholds,omitempty"`
+
+	// Metrics restarts a deployment when a PromQL expression evaluated for it holds, for
+	// example when its memory working set grows close to its limit.
+	// +optional
+	Metrics *MetricsTrigger `json:"metrics,omitempty"`
+}
+
+// ScheduleSpec specifies when rollouts are due.
+type ScheduleSpec struct {
+	// Interval specifies the time interval between rollouts, such as "12h" or "30m". If not
+	// specified, the default interval of the operator is used, which is 24h unless configured.
+	// +optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+	// +kubebuilder:validation:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="interval must be positive"
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// AnchorTime fixes the rollouts due by the Interval to AnchorTime plus a whole number of
+	// Intervals, instead of one Interval after the last rollout, so the schedule does not drift
+	// with reconcile latency or failures and is the same across RollingUpdates. No rollout is
+	// due by the Interval before AnchorTime.
+	// +optional
+	AnchorTime *metav1.Time `json:"anchorTime,omitempty"`
+
+	// MissedSchedulePolicy specifies what happens when a rollout due by the Interval was missed,
+	// for example because the operator was not running. A rollout is missed when it is late by
+	// more than the StartingDeadline, or by more than one Interval if no deadline is set.
+	// Valid values are:
+	// - "runImmediately": start the missed rollout right away;
+	// - "skip": drop the missed rollout and start the next one an Interval from now;
+	// - "runInNextWindow": start the rollout at the next time the schedule would have fired.
+	// The decision is recorded in the MissedSchedule status field.
+	// +optional
+	// +kubebuilder:validation:Enum=runImmediately;skip;runInNextWindow
+	// +kubebuilder:default=runImmediately
+	MissedSchedulePolicy MissedSchedulePolicy `json:"missedSchedulePolicy,omitempty"`
+
+	// StartingDeadline is how late a rollout due by the Interval may start before it is
+	// considered missed, such as "30m". It is similar to the startingDeadlineSeconds of a
+	// CronJob.
+	// +optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+	StartingDeadline *metav1.Duration `json:"startingDeadline,omitempty"`
+}
+
+// RestartTriggers lists the objects whose changes start a rollout.
+type RestartTriggers struct {
+	// Secrets selects Secrets in the namespace of the RollingUpdate.
+	// +optional
+	Secrets []ObjectSelector `json:"secrets,omitempty"`
+
+	// ConfigMaps selects ConfigMaps in the namespace of the RollingUpdate.
+	// +optional
+	ConfigMaps []ObjectSelector `json:"configMaps,omitempty"`
+
+	// AutoDiscover enables restarting a deployment when a Secret or ConfigMap its pod template
+	// consumes through volumes, envFrom or env valueFrom changes. Only the affected deployment
+	// is restarted.
+	// +optional
+	AutoDiscover bool `json:"autoDiscover,omitempty"`
+}
+
+// MetricsTrigger describes a PromQL expression evaluated for each targeted deployment against a
+// Prometheus compatible HTTP API.
+type MetricsTrigger struct {
+	// URL is the base URL of the Prometheus compatible HTTP API, such as
+	// "http://prometheus.monitoring.svc:9090".
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// Query is the PromQL expression evaluated for each deployment. The placeholders
+	// "$namespace" and "$deployment" are replaced with the namespace and name of the deployment.
+	// The condition holds when the query returns at least one sample, or a non-zero scalar.
+	Query string `json:"query"`
+
+	// Cooldown is the minimum duration between two restarts of the same deployment started by
+	// this trigger, such as "1h" or "30m".
+	// +optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+	// +kubebuilder:default="1h"
+	Cooldown *metav1.Duration `json:"cooldown,omitempty"`
+}
+
+// RestartThresholds specifies when the pods of a deployment are considered due for a restart.
+// A deployment is restarted as soon as any of the set thresholds is exceeded.
+type RestartThresholds struct {
+	// MaxPodAge restarts a deployment when its oldest pod is older than the given duration,
+	// such as "168h" or "90m".
+	// +optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+	MaxPodAge *metav1.Duration `json:"maxPodAge,omitempty"`
+
+	// MaxContainerRestarts restarts a deployment when a container of one of its pods restarted
+	// more than the given number of times.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxContainerRestarts *int32 `json:"maxContainerRestarts,omitempty"`
+}
+
+// ObjectSelector selects objects in the namespace of the RollingUpdate either by name or by
+// labels. If Name is set, MatchLabels is ignored. A selector without Name and MatchLabels
+// selects nothing.
+type ObjectSelector struct {
+	// Name is the name of the selected object.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// MatchLabels selects all objects that have all of the given labels.
+	// +optional
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+// RestartHooks specifies the Jobs run around restarts.
+type RestartHooks struct {
+	// PreRestart is run before the deployments are restarted. Restarts wait for the Job to complete.
+	// +optional
+	PreRestart *HookSpec `json:"preRestart,omitempty"`
+
+	// PostRestart is run after the restarted deployments completed their rollout and passed
+	// their verification checks.
+	// +optional
+	PostRestart *HookSpec `json:"postRestart,omitempty"`
+}
+
+// HookScope describes how often a hook is run during a rollout.
+type HookScope string
+
+const (
+	// HookScopeWorkload runs the hook once for every restarted deployment.
+	HookScopeWorkload HookScope = "Workload"
+
+	// HookScopeCycle runs the hook once for the whole rollout.
+	HookScopeCycle HookScope = "Cycle"
+)
+
+// HookSpec describes a Job run as a restart hook.
+type HookSpec struct {
+	// Scope specifies whether the hook runs once per restarted deployment ("Workload") or once
+	// per rollout ("Cycle").
+	// +optional
+	// +kubebuilder:validation:Enum=Workload;Cycle
+	// +kubebuilder:default=Workload
+	Scope HookScope `json:"scope,omitempty"`
+
+	// Timeout is the maximum duration the Job may run before the hook is considered failed,
+	// such as "5m" or "1h".
+	// +optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+	// +kubebuilder:default="10m"
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Template describes the Job to create. The Job is created in the namespace of the
+	// RollingUpdate and owned by it. Its containers receive the FLIPPER_HOOK, FLIPPER_ROLLINGUPDATE
+	// and, for workload scoped hooks, FLIPPER_DEPLOYMENT environment variables.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Type=object
+	Template batchv1.JobTemplateSpec `json:"template"`
+}
+
+// HTTPVerification describes an HTTP GET request sent to a Service to verify that a restarted
+// deployment is serving.
+type HTTPVerification struct {
+	// Deployment restricts the check to the rollout of the named deployment.
+	// If not specified, the check runs after the rollout of every restarted deployment.
+	// +optional
+	Deployment string `json:"deployment,omitempty"`
+
+	// Service is the name of the Service, in the namespace of the RollingUpdate, the request is sent to.
+	Service string `json:"service"`
+
+	// Port is the Service port the request is sent to.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+
+	// Path is the HTTP path requested. It must start with a slash.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^/`
+	// +kubebuilder:default="/"
+	Path string `json:"path,omitempty"`
+
+	// ExpectedStatus is the HTTP status code the response must have for the check to pass.
+	// +optional
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=599
+	// +kubebuilder:default=200
+	ExpectedStatus int32 `json:"expectedStatus,omitempty"`
+
+	// Timeout is the maximum duration of a single request, such as "5s" or "1m".
+	// +optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+	// +kubebuilder:default="5s"
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Retries is the number of times a failed check is retried before the deployment is
+	// considered failed. Retries are 15 seconds apart.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=3
+	Retries int32 `json:"retries,omitempty"`
+}
+
+// AnnotationSpec controls the annotations written by restarts. The restartedAt annotation is
+// always written to the pod template, since changing it is what restarts the pods.
+type AnnotationSpec struct {
+	// Placement selects where the annotations are written:
+	// - "Template": only to the pod template.
+	// - "TemplateAndObject": to the pod template and to the metadata of the deployment.
+	// +optional
+	// +kubebuilder:validation:Enum=Template;TemplateAndObject
+	// +kubebuilder:default=Template
+	Placement AnnotationPlacement `json:"placement,omitempty"`
+
+	// Exclude lists the restart annotations that are not written. The restartedAt annotation
+	// cannot be excluded.
+	// +optional
+	Exclude []RestartAnnotation `json:"exclude,omitempty"`
+
+	// Prefix replaces the prefix of the restartedBy, restartedByCR and restartedByCRDKind
+	// annotation keys, for example "example.com" writes "example.com/restartedByCR". The
+	// restartedAt annotation keeps the kubectl.kubernetes.io prefix, so restarts stay compatible
+	// with "kubectl rollout restart".
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// AnnotationPlacement describes where restart annotations are written.
+type AnnotationPlacement string
+
+const (
+	// AnnotationPlacementTemplate writes the annotations to the pod template only.
+	AnnotationPlacementTemplate AnnotationPlacement = "Template"
+
+	// AnnotationPlacementTemplateAndObject writes the annotations to the pod template and to the
+	// metadata of the deployment.
+	AnnotationPlacementTemplateAndObject AnnotationPlacement = "TemplateAndObject"
+)
+
+// RestartAnnotation names an optional restart annotation.
+// +kubebuilder:validation:Enum=restartedBy;restartedByCR;restartedByCRDKind
+type RestartAnnotation string
+
+const (
+	// RestartAnnotationRestartedBy names the annotation recording the operator.
+	RestartAnnotationRestartedBy RestartAnnotation = "restartedBy"
+
+	// RestartAnnotationRestartedByCR names the annotation recording the RollingUpdate.
+	RestartAnnotationRestartedByCR RestartAnnotation = "restartedByCR"
+
+	// RestartAnnotationRestartedByCRDKind names the annotation recording the kind of the
+	// RollingUpdate.
+	RestartAnnotationRestartedByCRDKind RestartAnnotation = "restartedByCRDKind"
+)
+
+// RestartMethod describes how a deployment is restarted.
+type RestartMethod string
+
+const (
+	// RestartMethodRolloutAnnotation restarts a deployment by updating its pod template annotations.
+	RestartMethodRolloutAnnotation RestartMethod = "rolloutAnnotation"
+
+	// RestartMethodEvictPods restarts a deployment by evicting its pods one at a time.
+	RestartMethodEvictPods RestartMethod = "evictPods"
+)
+
+// MissedSchedulePolicy describes how missed scheduled rollouts are handled.
+type MissedSchedulePolicy string
+
+const (
+	// MissedSchedulePolicyRunImmediately starts the missed rollout right away.
+	MissedSchedulePolicyRunImmediately MissedSchedulePolicy = "runImmediately"
+
+	// MissedSchedulePolicySkip drops the missed rollout and restarts the schedule.
+	MissedSchedulePolicySkip MissedSchedulePolicy = "skip"
+
+	// MissedSchedulePolicyRunInNextWindow starts the rollout at the next scheduled time.
+	MissedSchedulePolicyRunInNextWindow MissedSchedulePolicy = "runInNextWindow"
+)
+
+// RestartErrorPolicy describes how failed restarts are handled.
+type RestartErrorPolicy string
+
+const (
+	// RestartErrorPolicyContinue keeps restarting the other deployments of the rollout.
+	RestartErrorPolicyContinue RestartErrorPolicy = "continue"
+
+	// RestartErrorPolicyAbort skips the deployments of the rollout not restarted yet.
+	RestartErrorPolicyAbort RestartErrorPolicy = "abort"
+)
+
+// FailurePolicy describes how failed rollouts are handled.
+type FailurePolicy string
+
+const (
+	// FailurePolicyContinue records the failure and keeps following the schedule.
+	FailurePolicyContinue FailurePolicy = "continue"
+
+	// FailurePolicyPause stops starting new rollouts until the failed deployments recover.
+	FailurePolicyPause FailurePolicy = "pause"
+
+	// FailurePolicyRollback reverts the failed deployments and halts the remaining targets.
+	FailurePolicyRollback FailurePolicy = "rollback"
+)
+
+// PreflightSpec defines the pre-conditions checked before a rolling restart is started.
+type PreflightSpec struct {
+	// RequireDeploymentsAvailable requires every targeted deployment to report the Available
+	// condition before any of them is restarted.
+	// +optional
+	RequireDeploymentsAvailable bool `json:"requireDeploymentsAvailable,omitempty"`
+
+	// RequireNoUnschedulablePods requires that no pod in the namespace is pending because it
+	// cannot be scheduled. Unschedulable pods indicate the cluster has no spare capacity for
+	// the additional pods created during a rollout.
+	// +optional
+	RequireNoUnschedulablePods bool `json:"requireNoUnschedulablePods,omitempty"`
+
+	// MinReadyNodesPercent specifies the minimum percentage of cluster nodes that must be Ready
+	// and schedulable. If not specified, node readiness is not checked.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	MinReadyNodesPercent *int32 `json:"minReadyNodesPercent,omitempty"`
+}
+
+// RollingUpdateStatus defines the observed state of RollingUpdate
+type RollingUpdateStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// LastRolloutTime indicates the timestamp of the last rollout of all targets, which the
+	// Interval is counted from. Restarts of only some targets, such as those triggered by
+	// auto discovered objects, thresholds or metrics, do not change it. If not set, it indicates
+	// that no such rollout has been performed yet.
+	// +optional
+	LastRolloutTime metav1.Time `json:"lastRolloutTime,omitempty"`
+
+	// NextRolloutTime is when the next rollout is due by the Interval. It is not set when
+	// Thresholds are configured.
+	// +optional
+	NextRolloutTime *metav1.Time `json:"nextRolloutTime,omitempty"`
+
+	// TargetCount is the number of deployments selected by Selector when the RollingUpdate was
+	// last reconciled.
+	// +optional
+	TargetCount int32 `json:"targetCount"`
+
+	// CycleID identifies the latest rollout. It is the value written to the restartedAt
+	// annotation of the deployments restarted by the rollout, which lets a rollout interrupted
+	// by an operator restart resume without restarting a deployment twice.
+	// +optional
+	CycleID string `json:"cycleID,omitempty"`
+
+	// Targets lists the workloads restarted by the latest rollout of all targets, or all
+	// selected workloads if the latest rollout restarted only some of them. The workloads are
+	// in the namespace of the RollingUpdate.
+	// +optional
+	Targets []TargetReference `json:"targets,omitempty"`
+
+	// Deferred lists the workloads whose restart in the current rollout was deferred because
+	// a PodDisruptionBudget selecting their pods currently allows no disruptions.
+	// Deferred workloads are retried until the budget allows disruptions again or the next
+	// rollout starts.
+	// +optional
+	Deferred []DeferredTarget `json:"deferred,omitempty"`
+
+	// Workloads reports the rollout progress of each deployment restarted by the latest rollout.
+	// +optional
+	Workloads []WorkloadStatus `json:"workloads,omitempty"`
+
+	// AnnotatedTargets lists the workloads whose metadata the RollingUpdate wrote restart
+	// annotations to, with the keys written. The annotations are removed when the RollingUpdate
+	// is deleted.
+	// +optional
+	AnnotatedTargets []AnnotatedTarget `json:"annotatedTargets,omitempty"`
+
+	// PreRestartHook reports the cycle scoped pre-restart hook Job of the latest rollout.
+	// +optional
+	PreRestartHook *HookStatus `json:"preRestartHook,omitempty"`
+
+	// PostRestartHook reports the cycle scoped post-restart hook Job of the latest rollout.
+	// +optional
+	PostRestartHook *HookStatus `json:"postRestartHook,omitempty"`
+
+	// TriggerHashes records the hash of the data of each object selected by Triggers, as seen
+	// when the latest rollout started. Keys are of the form "Secret/name" or "ConfigMap/name".
+	// The hashes are HMAC-SHA256 keyed by the UID of the RollingUpdate, prefixed with
+	// "hmac-sha256:".
+	// +optional
+	TriggerHashes map[string]string `json:"triggerHashes,omitempty"`
+
+	// WorkloadTriggerHashes records, for each targeted deployment, the hash of the data of each
+	// Secret and ConfigMap discovered in its pod template when AutoDiscover is enabled. Keys of
+	// the inner maps have the same form as the keys of TriggerHashes.
+	// +optional
+	WorkloadTriggerHashes map[string]map[string]string `json:"workloadTriggerHashes,omitempty"`
+
+	// MetricsTriggeredAt records, for each deployment, when it was last restarted because the
+	// Metrics condition held. It is used to enforce the cooldown of the trigger.
+	// +optional
+	MetricsTriggeredAt map[string]metav1.Time `json:"metricsTriggeredAt,omitempty"`
+
+	// MissedSchedule records the decision taken for the last missed scheduled rollout.
+	// +optional
+	MissedSchedule *MissedSchedule `json:"missedSchedule,omitempty"`
+
+	// LastTrigger is the value of the flipper.example.com/trigger annotation handled last. A
+	// rollout is started when the annotation is set to a different value.
+	// +optional
+	LastTrigger string `json:"lastTrigger,omitempty"`
+
+	// History lists the latest rollouts, oldest first. At most ten rollouts are kept.
+	// +optional
+	History []RolloutRecord `json:"history,omitempty"`
+
+	// Conditions represent the latest available observations of the RollingUpdate's state.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// RolloutResult is the result of a completed rollout.
+type RolloutResult string
+
+const (
+	// RolloutResultSucceeded means all deployments of the rollout were restarted successfully.
+	RolloutResultSucceeded RolloutResult = "Succeeded"
+
+	// RolloutResultFailed means at least one deployment of the rollout failed.
+	RolloutResultFailed RolloutResult = "Failed"
+
+	// RolloutResultAborted means the rollout was aborted before all deployments were restarted.
+	RolloutResultAborted RolloutResult = "Aborted"
+)
+
+// RolloutRecord describes a past or in-progress rollout.
+type RolloutRecord struct {
+	// CycleID identifies the rollout.
+	CycleID string `json:"cycleID"`
+
+	// StartTime is when the rollout started.
+	StartTime metav1.Time `json:"startTime"`
+
+	// CompletionTime is when the rollout completed. It is not set while the rollout is in
+	// progress.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Reason describes what started the rollout: Scheduled, TriggerChanged, Condition or
+	// Manual.
+	Reason string `json:"reason"`
+
+	// Targets lists the workloads restarted by the rollout.
+	// +optional
+	Targets []TargetReference `json:"targets,omitempty"`
+
+	// Result is the result of the completed rollout.
+	// +optional
+	Result RolloutResult `json:"result,omitempty"`
+}
+
+// TargetKind is the kind of a workload restarted by a RollingUpdate.
+// +kubebuilder:validation:Enum=Deployment
+type TargetKind string
+
+const (
+	// TargetKindDeployment is the kind of Deployments.
+	TargetKindDeployment TargetKind = "Deployment"
+)
+
+// TargetReference identifies a workload in the namespace of the RollingUpdate.
+type TargetReference struct {
+	// Kind is the kind of the workload.
+	Kind TargetKind `json:"kind"`
+
+	// Name is the name of the workload.
+	Name string `json:"name"`
+}
+
+// DeferredTarget identifies a workload whose restart is deferred and the reason why.
+type DeferredTarget struct {
+	TargetReference `json:",inline"`
+
+	// PodDisruptionBudget is the name of the PodDisruptionBudget blocking the restart.
+	PodDisruptionBudget string `json:"podDisruptionBudget"`
+}
+
+// MissedScheduleDecision is the decision taken for a missed scheduled rollout.
+type MissedScheduleDecision string
+
+const (
+	// MissedScheduleRanImmediately means the missed rollout was started right away.
+	MissedScheduleRanImmediately MissedScheduleDecision = "RanImmediately"
+
+	// MissedScheduleSkipped means the missed rollout was dropped.
+	MissedScheduleSkipped MissedScheduleDecision = "Skipped"
+
+	// MissedSchedulePostponed means the missed rollout was moved to the next scheduled time.
+	MissedSchedulePostponed MissedScheduleDecision = "Postponed"
+)
+
+// MissedSchedule describes a missed scheduled rollout and how it was handled.
+type MissedSchedule struct {
+	// ScheduledTime is when the missed rollout was due.
+	ScheduledTime metav1.Time `json:"scheduledTime"`
+
+	// DetectedTime is when the operator noticed the rollout was missed.
+	DetectedTime metav1.Time `json:"detectedTime"`
+
+	// Decision is the decision taken, according to the MissedSchedulePolicy.
+	Decision MissedScheduleDecision `json:"decision"`
+
+	// NextTime is when the next rollout is due, if the missed rollout was not started.
+	// +optional
+	NextTime *metav1.Time `json:"nextTime,omitempty"`
+
+	// Message is a human readable description of the decision.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// WorkloadPhase is the rollout phase of a restarted workload.
+type WorkloadPhase string
+
+const (
+	// WorkloadPhasePending means the deployment was not restarted yet, possibly because it
+	// waits for a pre-restart hook to complete.
+	WorkloadPhasePending WorkloadPhase = "Pending"
+
+	// WorkloadPhaseRestarting means the restart was triggered and the rollout is in progress.
+	WorkloadPhaseRestarting WorkloadPhase = "Restarting"
+
+	// WorkloadPhaseVerifying means the rollout completed and the verification checks are running.
+	WorkloadPhaseVerifying WorkloadPhase = "Verifying"
+
+	// WorkloadPhaseFinalizing means the rollout was verified and the post-restart hook is running.
+	WorkloadPhaseFinalizing WorkloadPhase = "Finalizing"
+
+	// WorkloadPhaseDone means the rollout completed successfully.
+	WorkloadPhaseDone WorkloadPhase = "Done"
+
+	// WorkloadPhaseFailed means the rollout exceeded its progress deadline, the new pods are
+	// crash-looping, or the verification checks did not pass.
+	WorkloadPhaseFailed WorkloadPhase = "Failed"
+
+	// WorkloadPhaseRolledBack means the rollout failed and the restart annotations were reverted.
+	WorkloadPhaseRolledBack WorkloadPhase = "RolledBack"
+
+	// WorkloadPhaseAborted means the deployment was not restarted because the rollout was aborted.
+	WorkloadPhaseAborted WorkloadPhase = "Aborted"
+)
+
+// RestartResult is the result of the restart of a single workload.
+type RestartResult string
+
+const (
+	// RestartResultSucceeded means the workload was restarted.
+	RestartResultSucceeded RestartResult = "Succeeded"
+
+	// RestartResultFailed means the last attempt to restart the workload failed.
+	RestartResultFailed RestartResult = "Failed"
+
+	// RestartResultSkipped means the rollout was aborted before the workload was restarted.
+	RestartResultSkipped RestartResult = "Skipped"
+)
+
+// AnnotatedTarget identifies a workload whose metadata holds restart annotations written by the
+// RollingUpdate.
+type AnnotatedTarget struct {
+	TargetReference `json:",inline"`
+
+	// Annotations are the keys of the restart annotations written to the metadata of the
+	// workload.
+	Annotations []string `json:"annotations"`
+}
+
+// WorkloadStatus describes the rollout of a single restarted workload.
+type WorkloadStatus struct {
+	TargetReference `json:",inline"`
+
+	// Phase is the current rollout phase of the deployment.
+	Phase WorkloadPhase `json:"phase"`
+
+	// RestartedAt is the value written to the restartedAt pod template annotation. Pods created
+	// by the restart carry the same annotation value.
+	// +optional
+	RestartedAt string `json:"restartedAt,omitempty"`
+
+	// PreviousAnnotations holds the pod template values of the restart annotations before the
+	// restart. Annotations missing from the map were not set. They are restored on rollback.
+	// +optional
+	PreviousAnnotations map[string]string `json:"previousAnnotations,omitempty"`
+
+	// Result is the result of the restart of the deployment. It is empty until the restart was
+	// attempted.
+	// +optional
+	Result RestartResult `json:"result,omitempty"`
+
+	// RestartAttempts is the number of failed attempts to restart the deployment.
+	// +optional
+	RestartAttempts int32 `json:"restartAttempts,omitempty"`
+
+	// VerificationAttempts is the number of failed verification attempts.
+	// +optional
+	VerificationAttempts int32 `json:"verificationAttempts,omitempty"`
+
+	// PendingEvictions lists the pods still to be evicted, oldest first, when the deployment is
+	// restarted with the evictPods method.
+	// +optional
+	PendingEvictions []string `json:"pendingEvictions,omitempty"`
+
+	// EvictedPod is the pod evicted last. The next pod is evicted once it is gone and all
+	// replicas of the deployment are ready again.
+	// +optional
+	EvictedPod string `json:"evictedPod,omitempty"`
+
+	// HookJob is the name of the workload scoped hook Job the deployment is waiting for.
+	// +optional
+	HookJob string `json:"hookJob,omitempty"`
+
+	// Message is a human readable description of the restart or rollout failure, if any.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// HookPhase is the phase of a hook Job.
+type HookPhase string
+
+const (
+	// HookPhaseRunning means the hook Job has not finished yet.
+	HookPhaseRunning HookPhase = "Running"
+
+	// HookPhaseSucceeded means the hook Job completed successfully.
+	HookPhaseSucceeded HookPhase = "Succeeded"
+
+	// HookPhaseFailed means the hook Job failed or exceeded its timeout.
+	HookPhaseFailed HookPhase = "Failed"
+)
+
+// HookStatus describes a hook Job.
+type HookStatus struct {
+	// JobName is the name of the hook Job.
+	JobName string `json:"jobName"`
+
+	// Phase is the phase of the hook Job.
+	Phase HookPhase `json:"phase"`
+
+	// Message is a human readable description of the hook failure, if any.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	// ConditionPreflightFailed is set to True when a due rolling restart was deferred because
+	// one of the configured pre-flight checks did not pass.
+	ConditionPreflightFailed = "PreflightFailed"
+
+	// ConditionRolloutFailed is set to True when at least one deployment restarted by the latest
+	// rollout failed to roll out, or a hook of the latest rollout failed.
+	ConditionRolloutFailed = "RolloutFailed"
+
+	// ConditionPolicyViolated is set to True when the RollingUpdate violates the policy of the
+	// operator, which stops it from starting rollouts.
+	ConditionPolicyViolated = "PolicyViolated"
+
+	// ConditionReady summarizes the state of the RollingUpdate. It is False while the RollingUpdate
+	// violates the policy of the operator, while a due rollout is deferred by a pre-flight check or
+	// when the latest rollout failed, and Unknown while a rollout is in progress.
+	ConditionReady = "Ready"
+)
+
+// TriggerAnnotation requests a rollout of a RollingUpdate when it is set to a new value, such as
+// the current time.
+const TriggerAnnotation = "flipper.example.com/trigger"
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=ru,categories=flipper
+// +kubebuilder:printcolumn:name="Interval",type=string,JSONPath=`.spec.schedule.interval`
+// +kubebuilder:printcolumn:name="Last Rollout",type=date,JSONPath=`.status.lastRolloutTime`
+// +kubebuilder:printcolumn:name="Next Rollout",type=string,JSONPath=`.status.nextRolloutTime`
+// +kubebuilder:printcolumn:name="Targets",type=integer,JSONPath=`.status.targetCount`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Suspended",type=boolean,JSONPath=`.spec.suspend`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// RollingUpdate is the Schema for the rollingupdates API
+type RollingUpdate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RollingUpdateSpec   `json:"spec,omitempty"`
+	Status RollingUpdateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RollingUpdateList contains a list of RollingUpdate
+type RollingUpdateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RollingUpdate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RollingUpdate{}, &RollingUpdateList{})
+}
@@ -0,0 +1,630 @@
+//go:build !ignore_autogenerated
+
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnnotatedTarget) DeepCopyInto(out *AnnotatedTarget) {
+	*out = *in
+	out.TargetReference = in.TargetReference
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnnotatedTarget.
+func (in *AnnotatedTarget) DeepCopy() *AnnotatedTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(AnnotatedTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnnotationSpec) DeepCopyInto(out *AnnotationSpec) {
+	*out = *in
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]RestartAnnotation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnnotationSpec.
+func (in *AnnotationSpec) DeepCopy() *AnnotationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AnnotationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeferredTarget) DeepCopyInto(out *DeferredTarget) {
+	*out = *in
+	out.TargetReference = in.TargetReference
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeferredTarget.
+func (in *DeferredTarget) DeepCopy() *DeferredTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(DeferredTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPVerification) DeepCopyInto(out *HTTPVerification) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPVerification.
+func (in *HTTPVerification) DeepCopy() *HTTPVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookSpec) DeepCopyInto(out *HookSpec) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookSpec.
+func (in *HookSpec) DeepCopy() *HookSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HookSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookStatus) DeepCopyInto(out *HookStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookStatus.
+func (in *HookStatus) DeepCopy() *HookStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HookStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsTrigger) DeepCopyInto(out *MetricsTrigger) {
+	*out = *in
+	if in.Cooldown != nil {
+		in, out := &in.Cooldown, &out.Cooldown
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsTrigger.
+func (in *MetricsTrigger) DeepCopy() *MetricsTrigger {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsTrigger)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MissedSchedule) DeepCopyInto(out *MissedSchedule) {
+	*out = *in
+	in.ScheduledTime.DeepCopyInto(&out.ScheduledTime)
+	in.DetectedTime.DeepCopyInto(&out.DetectedTime)
+	if in.NextTime != nil {
+		in, out := &in.NextTime, &out.NextTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissedSchedule.
+func (in *MissedSchedule) DeepCopy() *MissedSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(MissedSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectSelector) DeepCopyInto(out *ObjectSelector) {
+	*out = *in
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectSelector.
+func (in *ObjectSelector) DeepCopy() *ObjectSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightSpec) DeepCopyInto(out *PreflightSpec) {
+	*out = *in
+	if in.MinReadyNodesPercent != nil {
+		in, out := &in.MinReadyNodesPercent, &out.MinReadyNodesPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightSpec.
+func (in *PreflightSpec) DeepCopy() *PreflightSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestartHooks) DeepCopyInto(out *RestartHooks) {
+	*out = *in
+	if in.PreRestart != nil {
+		in, out := &in.PreRestart, &out.PreRestart
+		*out = new(HookSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostRestart != nil {
+		in, out := &in.PostRestart, &out.PostRestart
+		*out = new(HookSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestartHooks.
+func (in *RestartHooks) DeepCopy() *RestartHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(RestartHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestartThresholds) DeepCopyInto(out *RestartThresholds) {
+	*out = *in
+	if in.MaxPodAge != nil {
+		in, out := &in.MaxPodAge, &out.MaxPodAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxContainerRestarts != nil {
+		in, out := &in.MaxContainerRestarts, &out.MaxContainerRestarts
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestartThresholds.
+func (in *RestartThresholds) DeepCopy() *RestartThresholds {
+	if in == nil {
+		return nil
+	}
+	out := new(RestartThresholds)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestartTriggers) DeepCopyInto(out *RestartTriggers) {
+	*out = *in
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]ObjectSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]ObjectSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestartTriggers.
+func (in *RestartTriggers) DeepCopy() *RestartTriggers {
+	if in == nil {
+		return nil
+	}
+	out := new(RestartTriggers)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdate) DeepCopyInto(out *RollingUpdate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdate.
+func (in *RollingUpdate) DeepCopy() *RollingUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RollingUpdate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateList) DeepCopyInto(out *RollingUpdateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RollingUpdate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateList.
+func (in *RollingUpdateList) DeepCopy() *RollingUpdateList {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RollingUpdateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateSpec) DeepCopyInto(out *RollingUpdateSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Schedule.DeepCopyInto(&out.Schedule)
+	if in.Preflight != nil {
+		in, out := &in.Preflight, &out.Preflight
+		*out = new(PreflightSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestartRetries != nil {
+		in, out := &in.RestartRetries, &out.RestartRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxEvictedPods != nil {
+		in, out := &in.MaxEvictedPods, &out.MaxEvictedPods
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = new(AnnotationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = make([]HTTPVerification, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(RestartHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Triggers != nil {
+		in, out := &in.Triggers, &out.Triggers
+		*out = new(RestartTriggers)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Thresholds != nil {
+		in, out := &in.Thresholds, &out.Thresholds
+		*out = new(RestartThresholds)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(MetricsTrigger)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateSpec.
+func (in *RollingUpdateSpec) DeepCopy() *RollingUpdateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateStatus) DeepCopyInto(out *RollingUpdateStatus) {
+	*out = *in
+	in.LastRolloutTime.DeepCopyInto(&out.LastRolloutTime)
+	if in.NextRolloutTime != nil {
+		in, out := &in.NextRolloutTime, &out.NextRolloutTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]TargetReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Deferred != nil {
+		in, out := &in.Deferred, &out.Deferred
+		*out = make([]DeferredTarget, len(*in))
+		copy(*out, *in)
+	}
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]WorkloadStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AnnotatedTargets != nil {
+		in, out := &in.AnnotatedTargets, &out.AnnotatedTargets
+		*out = make([]AnnotatedTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PreRestartHook != nil {
+		in, out := &in.PreRestartHook, &out.PreRestartHook
+		*out = new(HookStatus)
+		**out = **in
+	}
+	if in.PostRestartHook != nil {
+		in, out := &in.PostRestartHook, &out.PostRestartHook
+		*out = new(HookStatus)
+		**out = **in
+	}
+	if in.TriggerHashes != nil {
+		in, out := &in.TriggerHashes, &out.TriggerHashes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.WorkloadTriggerHashes != nil {
+		in, out := &in.WorkloadTriggerHashes, &out.WorkloadTriggerHashes
+		*out = make(map[string]map[string]string, len(*in))
+		for key, val := range *in {
+			var outVal map[string]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.MetricsTriggeredAt != nil {
+		in, out := &in.MetricsTriggeredAt, &out.MetricsTriggeredAt
+		*out = make(map[string]v1.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.MissedSchedule != nil {
+		in, out := &in.MissedSchedule, &out.MissedSchedule
+		*out = new(MissedSchedule)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]RolloutRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateStatus.
+func (in *RollingUpdateStatus) DeepCopy() *RollingUpdateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutRecord) DeepCopyInto(out *RolloutRecord) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]TargetReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutRecord.
+func (in *RolloutRecord) DeepCopy() *RolloutRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleSpec) DeepCopyInto(out *ScheduleSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.AnchorTime != nil {
+		in, out := &in.AnchorTime, &out.AnchorTime
+		*out = (*in).DeepCopy()
+	}
+	if in.StartingDeadline != nil {
+		in, out := &in.StartingDeadline, &out.StartingDeadline
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleSpec.
+func (in *ScheduleSpec) DeepCopy() *ScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetReference) DeepCopyInto(out *TargetReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetReference.
+func (in *TargetReference) DeepCopy() *TargetReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadStatus) DeepCopyInto(out *WorkloadStatus) {
+	*out = *in
+	out.TargetReference = in.TargetReference
+	if in.PreviousAnnotations != nil {
+		in, out := &in.PreviousAnnotations, &out.PreviousAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PendingEvictions != nil {
+		in, out := &in.PendingEvictions, &out.PendingEvictions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadStatus.
+func (in *WorkloadStatus) DeepCopy() *WorkloadStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+	"github.com/sigsegv1989/flipper-operator/internal/targets"
+)
+
+// runStatus lists the RollingUpdates in namespace, or describes the RollingUpdate named by args.
+func runStatus(ctx context.Context, c client.Client, namespace string, opts *options, args []string, out io.Writer) error {
+	if len(args) > 1 {
+		return fmt.Errorf("status accepts at most one RollingUpdate")
+	}
+	if len(args) == 1 {
+		rollingUpdate, err := getRollingUpdate(ctx, c, namespace, args)
+		if err != nil {
+			return err
+		}
+		return describeRollingUpdate(rollingUpdate, out)
+	}
+
+	listOptions := []client.ListOption{}
+	if !opts.allNamespaces {
+		listOptions = append(listOptions, client.InNamespace(namespace))
+	}
+	rollingUpdates := &flipperv1beta1.RollingUpdateList{}
+	if err := c.List(ctx, rollingUpdates, listOptions...); err != nil {
+		return fmt.Errorf("failed to list RollingUpdates: %v", err)
+	}
+	if len(rollingUpdates.Items) == 0 {
+		fmt.Fprintln(out, "No RollingUpdates found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	if opts.allNamespaces {
+		fmt.Fprint(w, "NAMESPACE\t")
+	}
+	fmt.Fprintln(w, "NAME\tINTERVAL\tSUSPENDED\tLAST ROLLOUT\tNEXT ROLLOUT\tWORKLOADS\tFAILED")
+	for _, rollingUpdate := range rollingUpdates.Items {
+		if opts.allNamespaces {
+			fmt.Fprintf(w, "%s\t", rollingUpdate.Namespace)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\t%t\n",
+			rollingUpdate.Name,
+			interval(&rollingUpdate),
+			rollingUpdate.Spec.Suspend,
+			since(rollingUpdate.Status.LastRolloutTime),
+			until(rollingUpdate.Status.NextRolloutTime),
+			workloadSummary(rollingUpdate.Status.Workloads),
+			meta.IsStatusConditionTrue(rollingUpdate.Status.Conditions, flipperv1beta1.ConditionRolloutFailed))
+	}
+	return w.Flush()
+}
+
+// describeRollingUpdate prints the state of rollingUpdate and of the workloads of its latest
+// rollout.
+func describeRollingUpdate(rollingUpdate *flipperv1beta1.RollingUpdate, out io.Writer) error {
+	status := rollingUpdate.Status
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", rollingUpdate.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", rollingUpdate.Namespace)
+	fmt.Fprintf(w, "Interval:\t%s\n", interval(rollingUpdate))
+	fmt.Fprintf(w, "Suspended:\t%t\n", rollingUpdate.Spec.Suspend)
+	fmt.Fprintf(w, "Last rollout:\t%s\n", since(status.LastRolloutTime))
+	fmt.Fprintf(w, "Next rollout:\t%s\n", until(status.NextRolloutTime))
+	if len(status.Deferred) > 0 {
+		deferred := []string{}
+		for _, entry := range status.Deferred {
+			deferred = append(deferred, fmt.Sprintf("%s (PodDisruptionBudget %s)", entry.Name, entry.PodDisruptionBudget))
+		}
+		fmt.Fprintf(w, "Deferred:\t%s\n", strings.Join(deferred, ", "))
+	}
+	for _, condition := range status.Conditions {
+		if condition.Status == metav1.ConditionTrue {
+			fmt.Fprintf(w, "Condition %s:\t%s\n", condition.Type, condition.Message)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(status.Workloads) == 0 {
+		return nil
+	}
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "DEPLOYMENT\tPHASE\tRESULT\tRESTARTED AT\tMESSAGE")
+	for _, workload := range status.Workloads {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			workload.Name, workload.Phase, orNone(string(workload.Result)), orNone(workload.RestartedAt), workload.Message)
+	}
+	return w.Flush()
+}
+
+// runTrigger starts a rollout of the RollingUpdate named by args by setting its trigger
+// annotation to the current time.
+func runTrigger(ctx context.Context, c client.Client, namespace string, _ *options, args []string, out io.Writer) error {
+	rollingUpdate, err := getRollingUpdate(ctx, c, namespace, args)
+	if err != nil {
+		return err
+	}
+
+	original := rollingUpdate.DeepCopy()
+	if rollingUpdate.Annotations == nil {
+		rollingUpdate.Annotations = map[string]string{}
+	}
+	rollingUpdate.Annotations[flipperv1beta1.TriggerAnnotation] = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := c.Patch(ctx, rollingUpdate, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to trigger RollingUpdate %s/%s: %v", namespace, rollingUpdate.Name, err)
+	}
+
+	fmt.Fprintf(out, "rollingupdate.flipper.example.com/%s triggered\n", rollingUpdate.Name)
+	if rollingUpdate.Spec.Suspend {
+		fmt.Fprintln(out, "The RollingUpdate is suspended, the rollout starts once it is resumed.")
+	}
+	return nil
+}
+
+// runSuspend stops the RollingUpdate named by args from starting new rollouts.
+func runSuspend(ctx context.Context, c client.Client, namespace string, _ *options, args []string, out io.Writer) error {
+	return setSuspend(ctx, c, namespace, args, true, out)
+}
+
+// runResume lets the RollingUpdate named by args start rollouts again.
+func runResume(ctx context.Context, c client.Client, namespace string, _ *options, args []string, out io.Writer) error {
+	return setSuspend(ctx, c, namespace, args, false, out)
+}
+
+func setSuspend(ctx context.Context, c client.Client, namespace string, args []string, suspend bool, out io.Writer) error {
+	rollingUpdate, err := getRollingUpdate(ctx, c, namespace, args)
+	if err != nil {
+		return err
+	}
+
+	action := "resumed"
+	if suspend {
+		action = "suspended"
+	}
+	if rollingUpdate.Spec.Suspend == suspend {
+		fmt.Fprintf(out, "rollingupdate.flipper.example.com/%s already %s\n", rollingUpdate.Name, action)
+		return nil
+	}
+
+	original := rollingUpdate.DeepCopy()
+	rollingUpdate.Spec.Suspend = suspend
+	if err := c.Patch(ctx, rollingUpdate, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to update RollingUpdate %s/%s: %v", namespace, rollingUpdate.Name, err)
+	}
+	fmt.Fprintf(out, "rollingupdate.flipper.example.com/%s %s\n", rollingUpdate.Name, action)
+	return nil
+}
+
+// runTargets lists the deployments selected by the RollingUpdate named by args, as the operator
+// selects them.
+func runTargets(ctx context.Context, c client.Client, namespace string, _ *options, args []string, out io.Writer) error {
+	rollingUpdate, err := getRollingUpdate(ctx, c, namespace, args)
+	if err != nil {
+		return err
+	}
+
+	deployments, err := targets.List(ctx, c, namespace, rollingUpdate.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("failed to list deployments in namespace %s: %v", namespace, err)
+	}
+	if len(deployments) == 0 {
+		fmt.Fprintf(out, "No deployments selected by RollingUpdate %s.\n", rollingUpdate.Name)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "DEPLOYMENT\tREADY\tRESTARTED AT")
+	for _, deployment := range deployments {
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		fmt.Fprintf(w, "%s\t%d/%d\t%s\n", deployment.Name, deployment.Status.ReadyReplicas, replicas,
+			orNone(deployment.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"]))
+	}
+	return w.Flush()
+}
+
+// runHistory lists the latest rollouts of the RollingUpdate named by args, oldest first.
+func runHistory(ctx context.Context, c client.Client, namespace string, _ *options, args []string, out io.Writer) error {
+	rollingUpdate, err := getRollingUpdate(ctx, c, namespace, args)
+	if err != nil {
+		return err
+	}
+	if len(rollingUpdate.Status.History) == 0 {
+		fmt.Fprintf(out, "No rollouts recorded for RollingUpdate %s.\n", rollingUpdate.Name)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "CYCLE\tREASON\tDURATION\tRESULT\tTARGETS")
+	for _, record := range rollingUpdate.Status.History {
+		took := "<in progress>"
+		result := "<in progress>"
+		if record.CompletionTime != nil {
+			took = duration.HumanDuration(record.CompletionTime.Sub(record.StartTime.Time))
+			result = string(record.Result)
+		}
+		targets := []string{}
+		for _, target := range record.Targets {
+			targets = append(targets, target.Name)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", record.CycleID, record.Reason, took, result, orNone(strings.Join(targets, ",")))
+	}
+	return w.Flush()
+}
+
+// getRollingUpdate returns the RollingUpdate in namespace named by the only element of args.
+func getRollingUpdate(ctx context.Context, c client.Client, namespace string, args []string) (*flipperv1beta1.RollingUpdate, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("exactly one RollingUpdate name is required")
+	}
+	rollingUpdate := &flipperv1beta1.RollingUpdate{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: args[0]}, rollingUpdate); err != nil {
+		return nil, fmt.Errorf("failed to get RollingUpdate %s/%s: %v", namespace, args[0], err)
+	}
+	return rollingUpdate, nil
+}
+
+// workloadSummary returns the number of settled workloads out of all workloads.
+func workloadSummary(workloads []flipperv1beta1.WorkloadStatus) string {
+	settled := 0
+	for _, workload := range workloads {
+		switch workload.Phase {
+		case flipperv1beta1.WorkloadPhaseDone,
+			flipperv1beta1.WorkloadPhaseFailed,
+			flipperv1beta1.WorkloadPhaseRolledBack,
+			flipperv1beta1.WorkloadPhaseAborted:
+			settled++
+		}
+	}
+	return fmt.Sprintf("%d/%d", settled, len(workloads))
+}
+
+// since returns how long ago t was, as kubectl prints ages.
+func since(t metav1.Time) string {
+	if t.IsZero() {
+		return "<never>"
+	}
+	return duration.HumanDuration(time.Since(t.Time)) + " ago"
+}
+
+// until returns how long until t, or how long ago t was if it passed.
+func until(t *metav1.Time) string {
+	if t == nil {
+		return "<none>"
+	}
+	if left := time.Until(t.Time); left > 0 {
+		return "in " + duration.HumanDuration(left)
+	}
+	return since(*t)
+}
+
+// interval returns the interval between the rollouts of rollingUpdate.
+func interval(rollingUpdate *flipperv1beta1.RollingUpdate) string {
+	if rollingUpdate.Spec.Schedule.Interval == nil {
+		return "<none>"
+	}
+	return rollingUpdate.Spec.Schedule.Interval.Duration.String()
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+var _ = Describe("Commands", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: "team-a", Name: "nginx"}
+
+	newClient := func(suspended bool, annotations map[string]string) client.Client {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(flipperv1beta1.AddToScheme(scheme)).To(Succeed())
+		rollingUpdate := &flipperv1beta1.RollingUpdate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name, Annotations: annotations},
+			Spec:       flipperv1beta1.RollingUpdateSpec{Suspend: suspended},
+		}
+		return fake.NewClientBuilder().WithScheme(scheme).WithObjects(rollingUpdate).Build()
+	}
+
+	DescribeTable("should set the trigger annotation",
+		func(suspended bool, annotations map[string]string, expectedOut string) {
+			c := newClient(suspended, annotations)
+			out := &bytes.Buffer{}
+			before := time.Now().UTC()
+			Expect(runTrigger(ctx, c, key.Namespace, &options{}, []string{key.Name}, out)).To(Succeed())
+			Expect(out.String()).To(Equal(expectedOut))
+
+			rollingUpdate := &flipperv1beta1.RollingUpdate{}
+			Expect(c.Get(ctx, key, rollingUpdate)).To(Succeed())
+			triggeredAt, err := time.Parse(time.RFC3339Nano, rollingUpdate.Annotations[flipperv1beta1.TriggerAnnotation])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(triggeredAt).NotTo(BeTemporally("<", before))
+			for k, v := range annotations {
+				if k != flipperv1beta1.TriggerAnnotation {
+					Expect(rollingUpdate.Annotations).To(HaveKeyWithValue(k, v))
+				}
+			}
+			Expect(rollingUpdate.Spec.Suspend).To(Equal(suspended))
+		},
+		Entry("without annotations", false, nil,
+			"rollingupdate.flipper.example.com/nginx triggered\n"),
+		Entry("replacing a previous trigger and keeping other annotations", false,
+			map[string]string{flipperv1beta1.TriggerAnnotation: "2024-06-18T12:00:00Z", "team": "web"},
+			"rollingupdate.flipper.example.com/nginx triggered\n"),
+		Entry("while suspended", true, nil,
+			"rollingupdate.flipper.example.com/nginx triggered\n"+
+				"The RollingUpdate is suspended, the rollout starts once it is resumed.\n"),
+	)
+
+	DescribeTable("should suspend and resume",
+		func(run command, suspended bool, expectedSuspend bool, expectedOut string) {
+			c := newClient(suspended, nil)
+			out := &bytes.Buffer{}
+			Expect(run(ctx, c, key.Namespace, &options{}, []string{key.Name}, out)).To(Succeed())
+			Expect(out.String()).To(Equal(expectedOut))
+
+			rollingUpdate := &flipperv1beta1.RollingUpdate{}
+			Expect(c.Get(ctx, key, rollingUpdate)).To(Succeed())
+			Expect(rollingUpdate.Spec.Suspend).To(Equal(expectedSuspend))
+		},
+		Entry("suspend a running RollingUpdate", command(runSuspend), false, true,
+			"rollingupdate.flipper.example.com/nginx suspended\n"),
+		Entry("suspend a suspended RollingUpdate", command(runSuspend), true, true,
+			"rollingupdate.flipper.example.com/nginx already suspended\n"),
+		Entry("resume a suspended RollingUpdate", command(runResume), true, false,
+			"rollingupdate.flipper.example.com/nginx resumed\n"),
+		Entry("resume a running RollingUpdate", command(runResume), false, false,
+			"rollingupdate.flipper.example.com/nginx already resumed\n"),
+	)
+
+	DescribeTable("should require the name of an existing RollingUpdate",
+		func(run command, args []string, expectedErr string) {
+			c := newClient(false, nil)
+			Expect(run(ctx, c, key.Namespace, &options{}, args, &bytes.Buffer{})).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("trigger without a name", command(runTrigger), []string{}, "exactly one RollingUpdate name is required"),
+		Entry("suspend with two names", command(runSuspend), []string{"nginx", "mysql"}, "exactly one RollingUpdate name is required"),
+		Entry("resume an unknown RollingUpdate", command(runResume), []string{"mysql"}, "failed to get RollingUpdate team-a/mysql"),
+	)
+})
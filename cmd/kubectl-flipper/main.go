@@ -0,0 +1,152 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kubectl-flipper is a kubectl plugin to inspect and operate RollingUpdates. Installed on the
+// PATH, it is run as "kubectl flipper".
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+const usage = `Inspect and operate flipper RollingUpdates.
+
+Usage:
+  kubectl flipper <command> [flags]
+
+Commands:
+  status [rollingupdate]     Show the state of all RollingUpdates, or the workloads of one
+  trigger <rollingupdate>    Start a rollout right away
+  suspend <rollingupdate>    Stop starting new rollouts
+  resume <rollingupdate>     Start rollouts again
+  targets <rollingupdate>    List the deployments selected by a RollingUpdate
+  history <rollingupdate>    List the latest rollouts of a RollingUpdate
+
+Flags:
+`
+
+// options holds the flags shared by all commands.
+type options struct {
+	kubeconfig    string
+	context       string
+	namespace     string
+	allNamespaces bool
+}
+
+// command runs a subcommand against the cluster with the positional arguments args.
+type command func(ctx context.Context, c client.Client, namespace string, opts *options, args []string, out io.Writer) error
+
+var commands = map[string]command{
+	"status":  runStatus,
+	"trigger": runTrigger,
+	"suspend": runSuspend,
+	"resume":  runResume,
+	"targets": runTargets,
+	"history": runHistory,
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(arguments []string, out io.Writer) error {
+	opts, name, args, err := parse(arguments, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = opts.kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: opts.context})
+
+	namespace := opts.namespace
+	if namespace == "" {
+		var err error
+		namespace, _, err = clientConfig.Namespace()
+		if err != nil {
+			return fmt.Errorf("failed to determine the namespace: %v", err)
+		}
+	}
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load the kubeconfig: %v", err)
+	}
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(flipperv1beta1.AddToScheme(scheme))
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("failed to create the client: %v", err)
+	}
+
+	return commands[name](context.Background(), c, namespace, opts, args, out)
+}
+
+// parse parses the command line arguments into the options, the name of the command and its
+// positional arguments. The usage is written to usageOut when asked for or when no command is
+// given.
+func parse(arguments []string, usageOut io.Writer) (*options, string, []string, error) {
+	opts := &options{}
+	fs := flag.NewFlagSet("kubectl-flipper", flag.ContinueOnError)
+	fs.SetOutput(usageOut)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use.")
+	fs.StringVar(&opts.context, "context", "", "The name of the kubeconfig context to use.")
+	fs.StringVar(&opts.namespace, "namespace", "", "The namespace of the RollingUpdates. Defaults to the namespace of the current context.")
+	fs.StringVar(&opts.namespace, "n", "", "Shorthand for --namespace.")
+	fs.BoolVar(&opts.allNamespaces, "all-namespaces", false, "List the RollingUpdates in all namespaces (status only).")
+	fs.BoolVar(&opts.allNamespaces, "A", false, "Shorthand for --all-namespaces.")
+
+	// Flags may appear before and after the command and its arguments, as with kubectl.
+	if err := fs.Parse(arguments); err != nil {
+		return nil, "", nil, err
+	}
+	args := []string{}
+	for fs.NArg() > 0 {
+		args = append(args, fs.Arg(0))
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return nil, "", nil, err
+		}
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return nil, "", nil, fmt.Errorf("no command given")
+	}
+	if _, ok := commands[args[0]]; !ok {
+		return nil, "", nil, fmt.Errorf("unknown command %q, see kubectl flipper -h", args[0])
+	}
+	return opts, args[0], args[1:], nil
+}
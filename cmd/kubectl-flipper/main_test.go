@@ -0,0 +1,65 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"flag"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parse", func() {
+	DescribeTable("should parse the flags before and after the command",
+		func(arguments []string, expectedOpts options, expectedName string, expectedArgs []string) {
+			opts, name, args, err := parse(arguments, &bytes.Buffer{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*opts).To(Equal(expectedOpts))
+			Expect(name).To(Equal(expectedName))
+			Expect(args).To(Equal(expectedArgs))
+		},
+		Entry("a command without flags", []string{"status"},
+			options{}, "status", []string{}),
+		Entry("flags before the command", []string{"--namespace", "team-a", "--context", "prod", "trigger", "nginx"},
+			options{namespace: "team-a", context: "prod"}, "trigger", []string{"nginx"}),
+		Entry("flags after the arguments", []string{"suspend", "nginx", "-n", "team-a", "--kubeconfig", "/tmp/config"},
+			options{namespace: "team-a", kubeconfig: "/tmp/config"}, "suspend", []string{"nginx"}),
+		Entry("flags between the arguments", []string{"status", "-A", "nginx"},
+			options{allNamespaces: true}, "status", []string{"nginx"}),
+		Entry("flags in the --flag=value form", []string{"resume", "--namespace=team-b", "nginx"},
+			options{namespace: "team-b"}, "resume", []string{"nginx"}),
+	)
+
+	DescribeTable("should reject invalid command lines",
+		func(arguments []string, expectedErr string) {
+			_, _, _, err := parse(arguments, &bytes.Buffer{})
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("no command", []string{"-n", "team-a"}, "no command given"),
+		Entry("an unknown command", []string{"restart", "nginx"}, `unknown command "restart"`),
+		Entry("an unknown flag", []string{"status", "--watch"}, "flag provided but not defined: -watch"),
+		Entry("a flag without a value", []string{"status", "-n"}, "flag needs an argument: -n"),
+	)
+
+	It("should print the usage when asked for", func() {
+		out := &bytes.Buffer{}
+		_, _, _, err := parse([]string{"-h"}, out)
+		Expect(err).To(MatchError(flag.ErrHelp))
+		Expect(out.String()).To(ContainSubstring("kubectl flipper <command> [flags]"))
+	})
+})
@@ -20,6 +20,7 @@ import (
 	"crypto/tls"
 	"flag"
 	"os"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -29,13 +30,19 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	flipperv1alpha1 "github.com/sigsegv1989/flipper-operator/api/v1alpha1"
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+	"github.com/sigsegv1989/flipper-operator/internal/config"
 	"github.com/sigsegv1989/flipper-operator/internal/controller"
+	"github.com/sigsegv1989/flipper-operator/internal/policy"
+	webhookv1beta1 "github.com/sigsegv1989/flipper-operator/internal/webhook/v1beta1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -48,6 +55,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(flipperv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(flipperv1beta1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -57,6 +65,10 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var fieldManager string
+	var watchNamespaces string
+	var policyFile string
+	var configFile string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metric endpoint binds to. "+
 		"Use the port :8080. If not set, it will be 0 in order to disable the metrics server")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -67,6 +79,17 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&fieldManager, "field-manager", "flipper-operator",
+		"The field manager recorded for the restart annotations patched into deployments")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma-separated namespaces the operator restarts deployments in. "+
+			"If not set, all namespaces are watched")
+	flag.StringVar(&policyFile, "policy-file", "",
+		"The policy file restricting the deployments RollingUpdates may restart. "+
+			"If not set, RollingUpdates are not restricted")
+	flag.StringVar(&configFile, "config", "",
+		"The FlipperConfig file configuring the operator, which is reloaded when it changes. "+
+			"Flags set on the command line take precedence over the file")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -95,8 +118,63 @@ func main() {
 		TLSOpts: tlsOpts,
 	})
 
+	var rolloutPolicy *policy.Policy
+	if policyFile != "" {
+		var err error
+		rolloutPolicy, err = policy.Load(policyFile)
+		if err != nil {
+			setupLog.Error(err, "unable to load policy")
+			os.Exit(1)
+		}
+	}
+
+	// Flags set on the command line override the config file, including when it is reloaded.
+	setFlags := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	overrideConfig := func(operatorConfig *config.FlipperConfig) {
+		if setFlags["field-manager"] {
+			operatorConfig.FieldManager = fieldManager
+		}
+		if setFlags["watch-namespaces"] {
+			operatorConfig.WatchNamespaces = parseNamespaces(watchNamespaces)
+		}
+		if setFlags["policy-file"] {
+			operatorConfig.Policy = rolloutPolicy
+		}
+	}
+
+	operatorConfig := &config.FlipperConfig{}
+	if configFile != "" {
+		var err error
+		operatorConfig, err = config.Load(configFile)
+		if err != nil {
+			setupLog.Error(err, "unable to load config")
+			os.Exit(1)
+		}
+	}
+	overrideConfig(operatorConfig)
+	configStore := config.NewStore(operatorConfig)
+
+	namespaces := operatorConfig.WatchNamespaces
+	cacheOpts := cache.Options{}
+	if len(namespaces) > 0 {
+		setupLog.Info("restricting the operator to namespaces", "namespaces", namespaces)
+		cacheOpts.DefaultNamespaces = map[string]cache.Config{}
+		for _, namespace := range namespaces {
+			cacheOpts.DefaultNamespaces[namespace] = cache.Config{}
+		}
+		// RollingUpdates are cached in all namespaces, so the ones outside the watched
+		// namespaces can be refused in their status.
+		cacheOpts.ByObject = map[client.Object]cache.ByObject{
+			&flipperv1beta1.RollingUpdate{}: {
+				Namespaces: map[string]cache.Config{cache.AllNamespaces: {}},
+			},
+		}
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
+		Cache:  cacheOpts,
 		Metrics: metricsserver.Options{
 			BindAddress:   metricsAddr,
 			SecureServing: secureMetrics,
@@ -124,14 +202,37 @@ func main() {
 	}
 
 	if err = (&controller.RollingUpdateReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		Recorder:       mgr.GetEventRecorderFor("flipper-operator"),
+		APIReader:      mgr.GetAPIReader(),
+		OperatorConfig: configStore,
+		Config:         mgr.GetConfig(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "RollingUpdate")
 		os.Exit(1)
 	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookv1beta1.SetupRollingUpdateWebhookWithManager(mgr, configStore); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "RollingUpdate")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	if configFile != "" {
+		if err := mgr.Add(&config.Watcher{
+			Path:     configFile,
+			Store:    configStore,
+			Override: overrideConfig,
+			Log:      ctrl.Log.WithName("config"),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up config watcher")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -147,3 +248,14 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// parseNamespaces splits the comma-separated namespaces of the --watch-namespaces flag.
+func parseNamespaces(value string) []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(value, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// namespaced-rbac generates the RBAC and manager flags of the namespace-scoped deployment of
+// the operator in config/namespaced. It splits the ClusterRole generated by controller-gen into
+// a Role for each watched namespace and a ClusterRole for the rules that must stay cluster-wide.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const header = "# Code generated by hack/namespaced-rbac. DO NOT EDIT.\n"
+
+// clusterResources are the resources the operator still accesses in all namespaces: nodes are
+// cluster-scoped, and RollingUpdates outside the watched namespaces are refused in their status.
+var clusterResources = map[string]bool{
+	"nodes":                     true,
+	"rollingupdates":            true,
+	"rollingupdates/status":     true,
+	"rollingupdates/finalizers": true,
+}
+
+func main() {
+	var namespaces, rolePath, output, namePrefix, serviceAccount, serviceAccountNamespace string
+	flag.StringVar(&namespaces, "namespaces", "", "Comma-separated namespaces watched by the operator")
+	flag.StringVar(&rolePath, "role", "config/rbac/role.yaml", "The ClusterRole generated by controller-gen")
+	flag.StringVar(&output, "output", "config/namespaced", "The directory the manifests are written to")
+	flag.StringVar(&namePrefix, "name-prefix", "flipper-operator-", "The name prefix of the deployment")
+	flag.StringVar(&serviceAccount, "service-account", "flipper-operator-controller-manager",
+		"The service account of the operator")
+	flag.StringVar(&serviceAccountNamespace, "service-account-namespace", "flipper-operator-system",
+		"The namespace of the service account of the operator")
+	flag.Parse()
+
+	var watched []string
+	for _, namespace := range strings.Split(namespaces, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			watched = append(watched, namespace)
+		}
+	}
+	if len(watched) == 0 {
+		fail(fmt.Errorf("no namespaces given, set --namespaces"))
+	}
+
+	data, err := os.ReadFile(rolePath)
+	if err != nil {
+		fail(err)
+	}
+	role := &rbacv1.ClusterRole{}
+	if err := yaml.Unmarshal(data, role); err != nil {
+		fail(fmt.Errorf("failed to parse ClusterRole %s: %v", rolePath, err))
+	}
+	namespacedRules, clusterRules := splitRules(role.Rules)
+
+	subjects := []rbacv1.Subject{{
+		Kind:      rbacv1.ServiceAccountKind,
+		Name:      serviceAccount,
+		Namespace: serviceAccountNamespace,
+	}}
+	objects := []interface{}{
+		&rbacv1.ClusterRole{
+			TypeMeta:   typeMeta("ClusterRole"),
+			ObjectMeta: metav1.ObjectMeta{Name: namePrefix + "manager-cluster-role"},
+			Rules:      clusterRules,
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   typeMeta("ClusterRoleBinding"),
+			ObjectMeta: metav1.ObjectMeta{Name: namePrefix + "manager-cluster-rolebinding"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: namePrefix + "manager-cluster-role"},
+			Subjects:   subjects,
+		},
+	}
+	for _, namespace := range watched {
+		objects = append(objects,
+			&rbacv1.Role{
+				TypeMeta:   typeMeta("Role"),
+				ObjectMeta: metav1.ObjectMeta{Name: namePrefix + "manager-role", Namespace: namespace},
+				Rules:      namespacedRules,
+			},
+			&rbacv1.RoleBinding{
+				TypeMeta:   typeMeta("RoleBinding"),
+				ObjectMeta: metav1.ObjectMeta{Name: namePrefix + "manager-rolebinding", Namespace: namespace},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: namePrefix + "manager-role"},
+				Subjects:   subjects,
+			})
+	}
+
+	manifests := bytes.NewBufferString(header)
+	for _, object := range objects {
+		out, err := yaml.Marshal(object)
+		if err != nil {
+			fail(err)
+		}
+		manifests.WriteString("---\n")
+		// The objects are never created, so their empty creation timestamp is dropped.
+		manifests.Write(bytes.ReplaceAll(out, []byte("  creationTimestamp: null\n"), nil))
+	}
+	if err := os.WriteFile(filepath.Join(output, "rbac.yaml"), manifests.Bytes(), 0o644); err != nil {
+		fail(err)
+	}
+
+	patch := []map[string]interface{}{{
+		"op":    "add",
+		"path":  "/spec/template/spec/containers/0/args/-",
+		"value": "--watch-namespaces=" + strings.Join(watched, ","),
+	}}
+	out, err := yaml.Marshal(patch)
+	if err != nil {
+		fail(err)
+	}
+	if err := os.WriteFile(filepath.Join(output, "manager_watch_namespaces_patch.yaml"), append([]byte(header), out...), 0o644); err != nil {
+		fail(err)
+	}
+}
+
+// splitRules splits rules into the rules granted in the watched namespaces and the rules
+// granted cluster-wide.
+func splitRules(rules []rbacv1.PolicyRule) (namespaced, cluster []rbacv1.PolicyRule) {
+	for _, rule := range rules {
+		var namespacedResources, clusterResourceNames []string
+		for _, resource := range rule.Resources {
+			if clusterResources[resource] {
+				clusterResourceNames = append(clusterResourceNames, resource)
+			} else {
+				namespacedResources = append(namespacedResources, resource)
+			}
+		}
+		if len(namespacedResources) > 0 {
+			namespacedRule := *rule.DeepCopy()
+			namespacedRule.Resources = namespacedResources
+			namespaced = append(namespaced, namespacedRule)
+		}
+		if len(clusterResourceNames) > 0 {
+			clusterRule := *rule.DeepCopy()
+			clusterRule.Resources = clusterResourceNames
+			cluster = append(cluster, clusterRule)
+		}
+	}
+	return namespaced, cluster
+}
+
+func typeMeta(kind string) metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: kind}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config implements the FlipperConfig file configuring the operator, which is reloaded
+// whenever it changes.
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+
+	"github.com/sigsegv1989/flipper-operator/internal/policy"
+)
+
+const (
+	// APIVersion is the version of the FlipperConfig file read by the operator.
+	APIVersion = "config.flipper.example.com/v1alpha1"
+
+	// Kind is the kind of the FlipperConfig file.
+	Kind = "FlipperConfig"
+
+	// DefaultFieldManager is the field manager of the restart patches if none is configured.
+	DefaultFieldManager = "flipper-operator"
+
+	// DefaultInterval is the interval between rollouts of a RollingUpdate without one, if none
+	// is configured.
+	DefaultInterval = 24 * time.Hour
+)
+
+// FlipperConfig configures the operator. Except for WatchNamespaces, changes of the file are
+// applied without restarting the operator.
+type FlipperConfig struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// FieldManager is the field manager recorded for the restart annotations patched into
+	// deployments, so GitOps tools can ignore them. Defaults to "flipper-operator".
+	FieldManager string `json:"fieldManager,omitempty"`
+
+	// WatchNamespaces restricts the operator to the RollingUpdates in these namespaces. If empty,
+	// all namespaces are watched. Changes are only applied when the operator restarts: a
+	// reloaded file keeps the namespaces the operator started with.
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+
+	// DefaultInterval is the interval between rollouts of RollingUpdates without one. Defaults
+	// to 24h.
+	DefaultInterval *metav1.Duration `json:"defaultInterval,omitempty"`
+
+	// AnnotationPrefix is the prefix of the restartedBy, restartedByCR and restartedByCRDKind
+	// annotations of RollingUpdates that do not set one.
+	AnnotationPrefix string `json:"annotationPrefix,omitempty"`
+
+	// MaxConcurrentRollouts is the largest number of RollingUpdates with a rollout in progress.
+	// Rollouts due while the limit is reached are deferred. If not set, rollouts are not limited.
+	MaxConcurrentRollouts *int32 `json:"maxConcurrentRollouts,omitempty"`
+
+	// Freezes are the periods during which no rollouts are started.
+	Freezes []Freeze `json:"freezes,omitempty"`
+
+	// Policy restricts the deployments RollingUpdates may restart. If not set, RollingUpdates
+	// are not restricted.
+	Policy *policy.Policy `json:"policy,omitempty"`
+
+	// MetricsURLs are the Prometheus compatible HTTP APIs the metrics trigger of RollingUpdates
+	// may query. The URL of a trigger is allowed if it has the scheme and host of one of them and
+	// its path starts with the path of it. If empty, the metrics trigger cannot be used, so
+	// RollingUpdates cannot make the operator send requests to arbitrary URLs.
+	MetricsURLs []string `json:"metricsURLs,omitempty"`
+}
+
+// Freeze is a period during which no rollouts are started, for example around a release or a
+// holiday. Rollouts due during a freeze are deferred until it ends; rollouts in progress when it
+// starts are completed.
+type Freeze struct {
+	// Name identifies the freeze in events and logs.
+	Name string `json:"name"`
+
+	// Start is when the freeze starts.
+	Start metav1.Time `json:"start"`
+
+	// End is when the freeze ends.
+	End metav1.Time `json:"end"`
+
+	// Namespaces restricts the freeze to the RollingUpdates in these namespaces. If empty, the
+	// freeze applies to all namespaces.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// Load reads the FlipperConfig file at path, applies its defaults and validates it.
+func Load(path string) (*FlipperConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %v", path, err)
+	}
+	config := &FlipperConfig{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	if config.APIVersion != APIVersion || config.Kind != Kind {
+		return nil, fmt.Errorf("config file %s must be a %s of version %s, got kind %q of version %q",
+			path, Kind, APIVersion, config.Kind, config.APIVersion)
+	}
+	config.Default()
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// Default sets the defaults of the unset fields of config.
+func (c *FlipperConfig) Default() {
+	if c.FieldManager == "" {
+		c.FieldManager = DefaultFieldManager
+	}
+	if c.DefaultInterval == nil {
+		c.DefaultInterval = &metav1.Duration{Duration: DefaultInterval}
+	}
+}
+
+// Validate returns an error describing the first invalid field of config, if any.
+func (c *FlipperConfig) Validate() error {
+	for _, namespace := range c.WatchNamespaces {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return fmt.Errorf("watchNamespaces: invalid namespace %q: %s", namespace, errs[0])
+		}
+	}
+	if c.DefaultInterval != nil && c.DefaultInterval.Duration <= 0 {
+		return fmt.Errorf("defaultInterval: must be positive, got %s", c.DefaultInterval.Duration)
+	}
+	if c.AnnotationPrefix != "" {
+		if errs := validation.IsDNS1123Subdomain(c.AnnotationPrefix); len(errs) > 0 {
+			return fmt.Errorf("annotationPrefix: %s", errs[0])
+		}
+	}
+	if c.MaxConcurrentRollouts != nil && *c.MaxConcurrentRollouts < 1 {
+		return fmt.Errorf("maxConcurrentRollouts: must be at least 1, got %d", *c.MaxConcurrentRollouts)
+	}
+	for i, freeze := range c.Freezes {
+		if freeze.Name == "" {
+			return fmt.Errorf("freezes[%d].name: must be set", i)
+		}
+		if !freeze.End.After(freeze.Start.Time) {
+			return fmt.Errorf("freezes[%d].end: must be after the start of freeze %s", i, freeze.Name)
+		}
+	}
+	for i, metricsURL := range c.MetricsURLs {
+		if _, err := parseMetricsURL(metricsURL); err != nil {
+			return fmt.Errorf("metricsURLs[%d]: %v", i, err)
+		}
+	}
+	if c.Policy != nil {
+		for namespace, rules := range c.Policy.Namespaces {
+			if err := validateRules(rules); err != nil {
+				return fmt.Errorf("policy.namespaces[%s]: %v", namespace, err)
+			}
+		}
+		if err := validateRules(c.Policy.Default); err != nil {
+			return fmt.Errorf("policy.default: %v", err)
+		}
+	}
+	return nil
+}
+
+func validateRules(rules policy.Rules) error {
+	if rules.MinInterval != nil && rules.MinInterval.Duration < 0 {
+		return fmt.Errorf("minInterval: must not be negative, got %s", rules.MinInterval.Duration)
+	}
+	if rules.MaxTargets != nil && *rules.MaxTargets < 0 {
+		return fmt.Errorf("maxTargets: must not be negative, got %d", *rules.MaxTargets)
+	}
+	return nil
+}
+
+// MetricsURLAllowed reports whether the metrics trigger of a RollingUpdate may query the API
+// at rawURL, which must match one of the MetricsURLs of config.
+func (c *FlipperConfig) MetricsURLAllowed(rawURL string) bool {
+	target, err := parseMetricsURL(rawURL)
+	if err != nil {
+		return false
+	}
+	for _, metricsURL := range c.MetricsURLs {
+		allowed, err := parseMetricsURL(metricsURL)
+		if err != nil {
+			continue
+		}
+		if !strings.EqualFold(target.Scheme, allowed.Scheme) || !strings.EqualFold(target.Host, allowed.Host) {
+			continue
+		}
+		prefix := strings.TrimSuffix(allowed.Path, "/")
+		if target.Path == prefix || strings.HasPrefix(target.Path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMetricsURL parses the base URL of a Prometheus compatible HTTP API. Credentials, queries,
+// fragments and relative path segments are rejected, as they could make a URL match an allowed
+// one while addressing another server or endpoint.
+func parseMetricsURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case parsed.Scheme != "http" && parsed.Scheme != "https":
+		return nil, fmt.Errorf("scheme of %q must be http or https", rawURL)
+	case parsed.Host == "":
+		return nil, fmt.Errorf("%q must have a host", rawURL)
+	case parsed.User != nil:
+		return nil, fmt.Errorf("%q must not have credentials", rawURL)
+	case parsed.RawQuery != "" || parsed.Fragment != "":
+		return nil, fmt.Errorf("%q must not have a query or fragment", rawURL)
+	case slices.Contains(strings.Split(parsed.Path, "/"), ".."):
+		return nil, fmt.Errorf("path of %q must not contain \"..\"", rawURL)
+	}
+	return parsed, nil
+}
+
+// ActiveFreeze returns the freeze of config preventing rollouts in namespace at now, if any.
+func (c *FlipperConfig) ActiveFreeze(namespace string, now time.Time) *Freeze {
+	for i, freeze := range c.Freezes {
+		if now.Before(freeze.Start.Time) || !now.Before(freeze.End.Time) {
+			continue
+		}
+		if len(freeze.Namespaces) > 0 && !slices.Contains(freeze.Namespaces, namespace) {
+			continue
+		}
+		return &c.Freezes[i]
+	}
+	return nil
+}
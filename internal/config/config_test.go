@@ -0,0 +1,155 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const header = "apiVersion: config.flipper.example.com/v1alpha1\nkind: FlipperConfig\n"
+
+var _ = Describe("FlipperConfig", func() {
+	writeConfig := func(path, contents string) {
+		Expect(os.WriteFile(path, []byte(contents), 0o600)).To(Succeed())
+	}
+
+	It("should load the sample config", func() {
+		loaded, err := Load(filepath.Join("..", "..", "config", "operator-config", "config.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded.DefaultInterval.Duration).To(Equal(24 * time.Hour))
+		Expect(*loaded.MaxConcurrentRollouts).To(Equal(int32(5)))
+		Expect(loaded.Freezes).To(HaveLen(1))
+		Expect(loaded.Policy.Default.AllowedKinds).To(Equal([]string{"Deployment"}))
+		Expect(loaded.MetricsURLAllowed("http://prometheus.monitoring.svc:9090")).To(BeTrue())
+	})
+
+	It("should apply the defaults", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		writeConfig(path, header)
+
+		loaded, err := Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded.FieldManager).To(Equal(DefaultFieldManager))
+		Expect(loaded.DefaultInterval.Duration).To(Equal(DefaultInterval))
+		Expect(loaded.MaxConcurrentRollouts).To(BeNil())
+		Expect(loaded.Policy).To(BeNil())
+		Expect((*Store)(nil).Get().DefaultInterval.Duration).To(Equal(DefaultInterval))
+	})
+
+	It("should reject invalid configs", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		for contents, message := range map[string]string{
+			"kind: FlipperConfig\n":                                                                             "must be a FlipperConfig of version config.flipper.example.com/v1alpha1",
+			header + "defaultIntervall: 1h\n":                                                                   "defaultIntervall",
+			header + "defaultInterval: 0s\n":                                                                    "defaultInterval: must be positive",
+			header + "annotationPrefix: Example_com\n":                                                          "annotationPrefix",
+			header + "maxConcurrentRollouts: 0\n":                                                               "maxConcurrentRollouts: must be at least 1",
+			header + "watchNamespaces: [Team-A]\n":                                                              "watchNamespaces: invalid namespace \"Team-A\"",
+			header + "policy:\n  default:\n    maxTargets: -1\n":                                                "policy.default: maxTargets: must not be negative",
+			header + "freezes:\n- start: 2024-12-20T00:00:00Z\n  end: 2025-01-06T00:00:00Z\n":                   "freezes[0].name: must be set",
+			header + "metricsURLs: [prometheus:9090]\n":                                                         "metricsURLs[0]: scheme of \"prometheus:9090\" must be http or https",
+			header + "freezes:\n- name: year-end\n  start: 2025-01-06T00:00:00Z\n  end: 2024-12-20T00:00:00Z\n": "freezes[0].end: must be after the start",
+		} {
+			writeConfig(path, contents)
+			_, err := Load(path)
+			Expect(err).To(MatchError(ContainSubstring(message)), contents)
+		}
+	})
+
+	It("should only allow metrics URLs matching one of the metricsURLs", func() {
+		config := &FlipperConfig{MetricsURLs: []string{"http://prometheus.monitoring.svc:9090", "https://thanos.example.com/api/prom/"}}
+		for metricsURL, allowed := range map[string]bool{
+			"http://prometheus.monitoring.svc:9090":                 true,
+			"http://prometheus.monitoring.svc:9090/":                true,
+			"HTTP://Prometheus.monitoring.svc:9090":                 true,
+			"https://thanos.example.com/api/prom":                   true,
+			"https://thanos.example.com/api/prom/tenant-a":          true,
+			"https://prometheus.monitoring.svc:9090":                false,
+			"http://prometheus.monitoring.svc:9091":                 false,
+			"http://prometheus.monitoring.svc:9090.evil.com":        false,
+			"http://admin@prometheus.monitoring.svc:9090":           false,
+			"http://prometheus.monitoring.svc:9090?x=1":             false,
+			"https://thanos.example.com/api/prometheus":             false,
+			"https://thanos.example.com/api/prom/../../admin":       false,
+			"https://thanos.example.com/api/prom/%2e%2e/%2e%2e/adm": false,
+			"http://kubernetes.default.svc":                         false,
+		} {
+			Expect(config.MetricsURLAllowed(metricsURL)).To(Equal(allowed), metricsURL)
+		}
+		Expect((&FlipperConfig{}).MetricsURLAllowed("http://prometheus.monitoring.svc:9090")).To(BeFalse())
+	})
+
+	It("should find the active freeze of a namespace", func() {
+		config := &FlipperConfig{Freezes: []Freeze{{
+			Name:       "release",
+			Start:      metav1.NewTime(time.Date(2024, 12, 20, 0, 0, 0, 0, time.UTC)),
+			End:        metav1.NewTime(time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)),
+			Namespaces: []string{"payments"},
+		}}}
+
+		during := time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC)
+		Expect(config.ActiveFreeze("payments", during).Name).To(Equal("release"))
+		Expect(config.ActiveFreeze("default", during)).To(BeNil())
+		Expect(config.ActiveFreeze("payments", time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC))).To(BeNil())
+		Expect(config.ActiveFreeze("payments", time.Date(2024, 12, 19, 0, 0, 0, 0, time.UTC))).To(BeNil())
+	})
+
+	It("should reload the config file when it changes", func() {
+		dir := GinkgoT().TempDir()
+		path := filepath.Join(dir, "config.yaml")
+		writeConfig(path, header+"defaultInterval: 12h\nwatchNamespaces: [team-a]\n")
+		loaded, err := Load(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		store := NewStore(loaded)
+		watcher := &Watcher{
+			Path:     path,
+			Store:    store,
+			Override: func(config *FlipperConfig) { config.FieldManager = "flipper-test" },
+			Log:      zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)),
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+		go func() {
+			defer GinkgoRecover()
+			Expect(watcher.Start(ctx)).To(Succeed())
+		}()
+
+		By("Applying a changed config")
+		Eventually(func() time.Duration {
+			writeConfig(path, header+"defaultInterval: 6h\nwatchNamespaces: [team-b]\n")
+			return store.Get().DefaultInterval.Duration
+		}).Should(Equal(6 * time.Hour))
+		Expect(store.Changes()).To(Receive())
+		Expect(store.Get().FieldManager).To(Equal("flipper-test"))
+		Expect(store.Get().WatchNamespaces).To(Equal([]string{"team-a"}))
+
+		By("Keeping the previous config when the file is invalid")
+		writeConfig(path, header+"defaultInterval: 0s\n")
+		Consistently(func() time.Duration {
+			return store.Get().DefaultInterval.Duration
+		}, "200ms").Should(Equal(6 * time.Hour))
+	})
+})
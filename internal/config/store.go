@@ -0,0 +1,75 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// Store holds the current FlipperConfig of the operator, which is replaced when the config file
+// changes.
+type Store struct {
+	mu     sync.RWMutex
+	config *FlipperConfig
+
+	// changes receives an event whenever the config is replaced. Pending events are coalesced.
+	changes chan event.GenericEvent
+}
+
+// NewStore returns a store holding config, with its defaults applied.
+func NewStore(config *FlipperConfig) *Store {
+	config.Default()
+	return &Store{
+		config:  config,
+		changes: make(chan event.GenericEvent, 1),
+	}
+}
+
+// Get returns the current config, which must not be modified. A nil store holds the defaults.
+func (s *Store) Get() *FlipperConfig {
+	if s == nil {
+		config := &FlipperConfig{}
+		config.Default()
+		return config
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// Set replaces the current config, with its defaults applied, and notifies the receivers of
+// Changes.
+func (s *Store) Set(config *FlipperConfig) {
+	config.Default()
+	s.mu.Lock()
+	s.config = config
+	s.mu.Unlock()
+
+	select {
+	case s.changes <- event.GenericEvent{}:
+	default:
+		// A change is already pending, its receiver reads the new config.
+	}
+}
+
+// Changes returns a channel receiving an event whenever the config is replaced, to be watched
+// through source.Channel.
+func (s *Store) Changes() <-chan event.GenericEvent {
+	return s.changes
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// Watcher reloads the config file into a Store whenever it changes. Invalid files are ignored,
+// the previous config is kept until the file is fixed.
+type Watcher struct {
+	// Path is the path of the config file.
+	Path string
+
+	// Store receives the reloaded config.
+	Store *Store
+
+	// Override is applied to every reloaded config before it is stored, for example to keep the
+	// settings given on the command line. Optional.
+	Override func(*FlipperConfig)
+
+	Log logr.Logger
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica reloads its
+// config, so the webhooks of the replicas that are not the leader enforce the same policy.
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable. It watches the directory of the config file rather than the
+// file itself: ConfigMap volumes replace the file through a symlink swap of their ..data
+// directory, which a watch on the file does not notice.
+func (w *Watcher) Start(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config file %s: %v", w.Path, err)
+	}
+	defer watcher.Close()
+
+	dir := filepath.Dir(w.Path)
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch config directory %s: %v", dir, err)
+	}
+	w.Log.Info("Watching config file for changes", "path", w.Path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !w.affectsConfig(event) {
+				continue
+			}
+			w.Log.V(1).Info("Config file changed", "event", event)
+			w.reload()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			w.Log.Error(err, "Failed to watch config file", "path", w.Path)
+		}
+	}
+}
+
+// affectsConfig reports whether event may have changed the contents of the config file.
+func (w *Watcher) affectsConfig(event fsnotify.Event) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+		return false
+	}
+	name := filepath.Base(event.Name)
+	return filepath.Clean(event.Name) == filepath.Clean(w.Path) || name == "..data"
+}
+
+// reload loads the config file into the store, unless it is invalid or unchanged.
+func (w *Watcher) reload() {
+	config, err := Load(w.Path)
+	if err != nil {
+		w.Log.Error(err, "Failed to reload config file, keeping the previous config", "path", w.Path)
+		return
+	}
+	if w.Override != nil {
+		w.Override(config)
+	}
+
+	previous := w.Store.Get()
+	if !slices.Equal(config.WatchNamespaces, previous.WatchNamespaces) {
+		w.Log.Info("The watched namespaces changed, restart the operator to apply them",
+			"watchNamespaces", previous.WatchNamespaces, "configured", config.WatchNamespaces)
+		config.WatchNamespaces = previous.WatchNamespaces
+	}
+	if equality.Semantic.DeepEqual(config, previous) {
+		return
+	}
+	w.Store.Set(config)
+	w.Log.Info("Reloaded config file", "path", w.Path)
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// restartAnnotations returns the annotations written by a restart at restartedAt, as selected
+// by the annotation options of rollingUpdate.
+func (r *RollingUpdateReconciler) restartAnnotations(rollingUpdate *flipperv1beta1.RollingUpdate, restartedAt string) map[string]string {
+	annotations := map[string]string{
+		restartedAtAnnotation: restartedAt,
+	}
+
+	values := map[flipperv1beta1.RestartAnnotation]string{
+		flipperv1beta1.RestartAnnotationRestartedBy:        "flipper-operator",
+		flipperv1beta1.RestartAnnotationRestartedByCR:      fmt.Sprintf("%s/%s", rollingUpdate.Namespace, rollingUpdate.Name),
+		flipperv1beta1.RestartAnnotationRestartedByCRDKind: "rollingupdate",
+	}
+	for name, value := range values {
+		if spec := rollingUpdate.Spec.Annotations; spec != nil && slices.Contains(spec.Exclude, name) {
+			continue
+		}
+		annotations[r.restartAnnotationKey(rollingUpdate, name)] = value
+	}
+	return annotations
+}
+
+// restartAnnotationKeys returns the keys of all annotations a restart of rollingUpdate may
+// write, regardless of the excluded ones.
+func (r *RollingUpdateReconciler) restartAnnotationKeys(rollingUpdate *flipperv1beta1.RollingUpdate) []string {
+	return []string{
+		restartedAtAnnotation,
+		r.restartAnnotationKey(rollingUpdate, flipperv1beta1.RestartAnnotationRestartedBy),
+		r.restartAnnotationKey(rollingUpdate, flipperv1beta1.RestartAnnotationRestartedByCR),
+		r.restartAnnotationKey(rollingUpdate, flipperv1beta1.RestartAnnotationRestartedByCRDKind),
+	}
+}
+
+// restartAnnotationKey returns the key of the optional annotation name, honoring the prefix of
+// rollingUpdate, or else the prefix of the operator config.
+func (r *RollingUpdateReconciler) restartAnnotationKey(rollingUpdate *flipperv1beta1.RollingUpdate, name flipperv1beta1.RestartAnnotation) string {
+	if spec := rollingUpdate.Spec.Annotations; spec != nil && spec.Prefix != "" {
+		return spec.Prefix + "/" + string(name)
+	}
+	if prefix := r.OperatorConfig.Get().AnnotationPrefix; prefix != "" {
+		return prefix + "/" + string(name)
+	}
+	switch name {
+	case flipperv1beta1.RestartAnnotationRestartedBy:
+		return restartedByAnnotation
+	case flipperv1beta1.RestartAnnotationRestartedByCR:
+		return restartedByCRAnnotation
+	default:
+		return restartedByKindAnnotation
+	}
+}
+
+// annotateObject reports whether the restart annotations of rollingUpdate are also written to the
+// metadata of the deployments.
+func annotateObject(rollingUpdate *flipperv1beta1.RollingUpdate) bool {
+	spec := rollingUpdate.Spec.Annotations
+	return spec != nil && spec.Placement == flipperv1beta1.AnnotationPlacementTemplateAndObject
+}
+
+// recordAnnotatedTarget records in the status of rollingUpdate that the keys of annotations were
+// written to the metadata of the deployment name. Keys recorded by previous rollouts are kept, so
+// keys written with a previous prefix are cleaned up as well.
+func recordAnnotatedTarget(rollingUpdate *flipperv1beta1.RollingUpdate, name string, annotations map[string]string) {
+	status := &rollingUpdate.Status
+	index := slices.IndexFunc(status.AnnotatedTargets, func(target flipperv1beta1.AnnotatedTarget) bool {
+		return target.Kind == flipperv1beta1.TargetKindDeployment && target.Name == name
+	})
+	if index < 0 {
+		status.AnnotatedTargets = append(status.AnnotatedTargets, flipperv1beta1.AnnotatedTarget{
+			TargetReference: flipperv1beta1.TargetReference{Kind: flipperv1beta1.TargetKindDeployment, Name: name},
+		})
+		index = len(status.AnnotatedTargets) - 1
+	}
+
+	target := &status.AnnotatedTargets[index]
+	for key := range annotations {
+		if !slices.Contains(target.Annotations, key) {
+			target.Annotations = append(target.Annotations, key)
+		}
+	}
+	slices.Sort(target.Annotations)
+}
+
+// cleanupAnnotations removes the restart annotations written to the metadata of the deployments
+// by rollingUpdate. The pod templates are left unchanged, since changing them would restart the
+// pods. The deployments are found through status.annotatedTargets, and through the value of
+// their restartedByCR annotation, whatever its prefix, so deployments annotated before the
+// status recorded them are cleaned up as well. Recorded annotations of a deployment whose
+// restartedByCR annotation names another RollingUpdate were overwritten by it and are kept.
+//
+// The deployments are listed and patched with the client of targetClient. If the service account
+// of rollingUpdate may not do so, for example because it was deleted together with the
+// RollingUpdate, the annotations are left in place with a CleanupForbidden warning event, so the
+// deletion is not blocked.
+func (r *RollingUpdateReconciler) cleanupAnnotations(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) error {
+	namespace := rollingUpdate.Namespace
+	log := r.Log.WithValues("namespace", namespace, "name", rollingUpdate.Name)
+
+	c, err := r.targetClient(rollingUpdate)
+	if err != nil {
+		return err
+	}
+	forbidden := func(err error) bool {
+		if !errors.IsForbidden(err) || rollingUpdate.Spec.ServiceAccountName == "" {
+			return false
+		}
+		log.Info("Service account may not remove the restart annotations, leaving them", "serviceAccount", rollingUpdate.Spec.ServiceAccountName, "error", err.Error())
+		r.Recorder.Eventf(rollingUpdate, corev1.EventTypeWarning, "CleanupForbidden",
+			"Service account %s may not remove the restart annotations of the deployments: %v", rollingUpdate.Spec.ServiceAccountName, err)
+		return true
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := c.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+		if forbidden(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to list deployments in namespace %s: %v", namespace, err)
+	}
+
+	recorded := map[string][]string{}
+	for _, target := range rollingUpdate.Status.AnnotatedTargets {
+		if target.Kind == flipperv1beta1.TargetKindDeployment {
+			recorded[target.Name] = target.Annotations
+		}
+	}
+
+	owner := fmt.Sprintf("%s/%s", namespace, rollingUpdate.Name)
+	for _, deployment := range deployments.Items {
+		keys := staleAnnotationKeys(deployment.Annotations, owner)
+		if len(keys) > 0 || !annotatedByOther(deployment.Annotations, owner) {
+			for _, key := range recorded[deployment.Name] {
+				if _, ok := deployment.Annotations[key]; ok && !slices.Contains(keys, key) {
+					keys = append(keys, key)
+				}
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		original := deployment.DeepCopy()
+		for _, key := range keys {
+			delete(deployment.Annotations, key)
+		}
+		err := c.Patch(ctx, &deployment, client.MergeFrom(original), client.FieldOwner(r.fieldManager()))
+		if err != nil {
+			if forbidden(err) {
+				return nil
+			}
+			log.Error(err, "Failed to remove restart annotations", "deployment", deployment.Name)
+			return fmt.Errorf("failed to patch Deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
+		}
+		log.Info("Removed restart annotations from deployment", "deployment", deployment.Name, "annotations", keys)
+	}
+	return nil
+}
+
+// annotatedByOther reports whether annotations hold a restartedByCR annotation naming another
+// RollingUpdate than owner.
+func annotatedByOther(annotations map[string]string, owner string) bool {
+	for key, value := range annotations {
+		_, name, found := strings.Cut(key, "/")
+		if found && name == string(flipperv1beta1.RestartAnnotationRestartedByCR) && value != owner {
+			return true
+		}
+	}
+	return false
+}
+
+// staleAnnotationKeys returns the restart annotation keys in annotations written on behalf of
+// owner, identified by a restartedByCR annotation with the value owner.
+func staleAnnotationKeys(annotations map[string]string, owner string) []string {
+	prefixes := []string{}
+	for key, value := range annotations {
+		prefix, name, found := strings.Cut(key, "/")
+		if found && name == string(flipperv1beta1.RestartAnnotationRestartedByCR) && value == owner {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return nil
+	}
+	// The default keys use both the kubectl.kubernetes.io and the flipper.example.com prefix.
+	prefixes = append(prefixes, "kubectl.kubernetes.io", "flipper.example.com")
+
+	names := []string{
+		"restartedAt",
+		string(flipperv1beta1.RestartAnnotationRestartedBy),
+		string(flipperv1beta1.RestartAnnotationRestartedByCR),
+		string(flipperv1beta1.RestartAnnotationRestartedByCRDKind),
+	}
+	keys := []string{}
+	for key := range annotations {
+		prefix, name, found := strings.Cut(key, "/")
+		if found && slices.Contains(prefixes, prefix) && slices.Contains(names, name) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
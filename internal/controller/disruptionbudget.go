@@ -0,0 +1,144 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// deferredRequeueInterval is how often deployments deferred by a PodDisruptionBudget are
+// checked again.
+const deferredRequeueInterval = 30 * time.Second
+
+// filterDisruptionBudgets splits deployments targeted by rollingUpdate into the ones that can be
+// restarted now and the ones whose pods are selected by a PodDisruptionBudget that currently
+// allows no disruptions. The budgets are listed with the client of targetClient.
+func (r *RollingUpdateReconciler) filterDisruptionBudgets(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, deployments []appsv1.Deployment) ([]appsv1.Deployment, []flipperv1beta1.DeferredTarget, error) {
+	namespace := rollingUpdate.Namespace
+	log := r.Log.WithValues("namespace", namespace)
+
+	c, err := r.targetClient(rollingUpdate)
+	if err != nil {
+		return nil, nil, err
+	}
+	pdbs := &policyv1.PodDisruptionBudgetList{}
+	if err := c.List(ctx, pdbs, client.InNamespace(namespace)); err != nil {
+		return nil, nil, fmt.Errorf("failed to list PodDisruptionBudgets in namespace %s: %v", namespace, err)
+	}
+	log.V(1).Info("PodDisruptionBudgets listed", "pdbCount", len(pdbs.Items))
+
+	ready := []appsv1.Deployment{}
+	deferred := []flipperv1beta1.DeferredTarget{}
+	for _, deployment := range deployments {
+		pdb, err := blockingDisruptionBudget(pdbs.Items, &deployment)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pdb == "" {
+			ready = append(ready, deployment)
+			continue
+		}
+
+		log.V(1).Info("Deferring deployment restart, PodDisruptionBudget allows no disruptions", "name", deployment.Name, "pdb", pdb)
+		deferred = append(deferred, flipperv1beta1.DeferredTarget{
+			TargetReference:     deploymentReference(deployment.Name),
+			PodDisruptionBudget: pdb,
+		})
+	}
+
+	return ready, deferred, nil
+}
+
+// retryDeferredDeployments adds the deferred deployments of rollingUpdate whose
+// PodDisruptionBudgets allow disruptions again to the current rollout as pending workloads.
+func (r *RollingUpdateReconciler) retryDeferredDeployments(ctx context.Context, req ctrl.Request, rollingUpdate *flipperv1beta1.RollingUpdate) error {
+	log := r.Log.WithValues("namespace", req.Namespace, "name", req.Name)
+
+	c, err := r.targetClient(rollingUpdate)
+	if err != nil {
+		return err
+	}
+	targets := []appsv1.Deployment{}
+	for _, entry := range rollingUpdate.Status.Deferred {
+		deployment := appsv1.Deployment{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: entry.Name}, &deployment)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				log.V(1).Info("Deferred deployment no longer exists", "deployment", entry.Name)
+				continue
+			}
+			return fmt.Errorf("failed to get Deployment %s/%s: %v", req.Namespace, entry.Name, err)
+		}
+		targets = append(targets, deployment)
+	}
+
+	ready, deferred, err := r.filterDisruptionBudgets(ctx, rollingUpdate, targets)
+	if err != nil {
+		return err
+	}
+
+	restarted := r.planWorkloads(rollingUpdate, ready)
+	for _, workload := range restarted {
+		r.Recorder.Eventf(rollingUpdate, corev1.EventTypeNormal, "DeferredRestarted",
+			"Resumed restart of deployment %s after its PodDisruptionBudget allowed disruptions", workload.Name)
+	}
+
+	// The targets of a partial rollout already list all selected deployments.
+	for _, target := range workloadTargets(restarted) {
+		if !slices.Contains(rollingUpdate.Status.Targets, target) {
+			rollingUpdate.Status.Targets = append(rollingUpdate.Status.Targets, target)
+		}
+	}
+	rollingUpdate.Status.Workloads = append(rollingUpdate.Status.Workloads, restarted...)
+	rollingUpdate.Status.Deferred = deferred
+	return nil
+}
+
+// blockingDisruptionBudget returns the name of the first PodDisruptionBudget in pdbs that
+// selects the pods of deployment and allows no disruptions, or an empty string if none does.
+func blockingDisruptionBudget(pdbs []policyv1.PodDisruptionBudget, deployment *appsv1.Deployment) (string, error) {
+	podLabels := labels.Set(deployment.Spec.Template.Labels)
+	for _, pdb := range pdbs {
+		if pdb.Spec.Selector == nil {
+			// A nil selector selects no pods in policy/v1.
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			return "", fmt.Errorf("invalid selector in PodDisruptionBudget %s/%s: %v", pdb.Namespace, pdb.Name, err)
+		}
+		if selector.Matches(podLabels) && pdb.Status.DisruptionsAllowed == 0 {
+			return pdb.Name, nil
+		}
+	}
+	return "", nil
+}
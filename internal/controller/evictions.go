@@ -0,0 +1,155 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// planEvictions starts the restart of deployment with the evictPods method by recording the
+// pods to evict in workload, oldest first. No pod is evicted yet. Pods created after the start
+// of the current rollout are replacements of already evicted pods, so a resumed rollout does
+// not evict them again.
+func (r *RollingUpdateReconciler) planEvictions(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, workload *flipperv1beta1.WorkloadStatus, deployment *appsv1.Deployment) error {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+
+	pods, err := r.listDeploymentPods(ctx, rollingUpdate, deployment)
+	if err != nil {
+		return err
+	}
+	pods = slices.DeleteFunc(pods, func(pod corev1.Pod) bool {
+		return !pod.CreationTimestamp.Time.Before(rolloutStartTime(rollingUpdate))
+	})
+	sort.SliceStable(pods, func(i, j int) bool {
+		return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+	})
+	if max := rollingUpdate.Spec.MaxEvictedPods; max != nil && len(pods) > int(*max) {
+		pods = pods[:*max]
+	}
+
+	evictions := []string{}
+	for _, pod := range pods {
+		evictions = append(evictions, pod.Name)
+	}
+	log.V(1).Info("Planned pod evictions", "deployment", deployment.Name, "pods", evictions)
+
+	workload.Phase = flipperv1beta1.WorkloadPhaseRestarting
+	workload.RestartedAt = cycleID(rollingUpdate)
+	workload.PendingEvictions = evictions
+	return nil
+}
+
+// continueEvictions evicts the next pending pod of workload once the replacement of the pod
+// evicted last is ready. It reports whether all planned pods were evicted and replaced. Pods are
+// evicted with the permissions of the service account of rollingUpdate, if any.
+func (r *RollingUpdateReconciler) continueEvictions(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, workload *flipperv1beta1.WorkloadStatus, deployment *appsv1.Deployment) (bool, error) {
+	log := r.Log.WithValues("namespace", deployment.Namespace, "name", deployment.Name)
+
+	c, err := r.targetClient(rollingUpdate)
+	if err != nil {
+		return false, err
+	}
+
+	pods, err := r.listDeploymentPods(ctx, rollingUpdate, deployment)
+	if err != nil {
+		return false, err
+	}
+
+	if workload.EvictedPod != "" {
+		if !deploymentPodsReady(deployment, pods, workload.EvictedPod) {
+			log.V(1).Info("Waiting for the replacement of the evicted pod", "pod", workload.EvictedPod)
+			return false, nil
+		}
+		workload.EvictedPod = ""
+	}
+
+	for len(workload.PendingEvictions) > 0 {
+		name := workload.PendingEvictions[0]
+
+		pod := &corev1.Pod{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: deployment.Namespace, Name: name}, pod)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				log.V(1).Info("Pod to evict no longer exists", "pod", name)
+				workload.PendingEvictions = workload.PendingEvictions[1:]
+				continue
+			}
+			return false, fmt.Errorf("failed to get Pod %s/%s: %v", deployment.Namespace, name, err)
+		}
+
+		eviction := &policyv1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		}
+		err = c.SubResource("eviction").Create(ctx, pod, eviction)
+		if err != nil {
+			if errors.IsTooManyRequests(err) {
+				// The eviction would violate a PodDisruptionBudget, it is retried later.
+				log.V(1).Info("Eviction blocked by a PodDisruptionBudget", "pod", name)
+				workload.Message = fmt.Sprintf("eviction of pod %s is blocked by a PodDisruptionBudget", name)
+				return false, nil
+			}
+			if errors.IsNotFound(err) {
+				workload.PendingEvictions = workload.PendingEvictions[1:]
+				continue
+			}
+			log.Error(err, "Failed to evict pod", "pod", name)
+			return false, fmt.Errorf("failed to evict Pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+
+		log.Info("Successfully evicted pod", "pod", name)
+		workload.PendingEvictions = workload.PendingEvictions[1:]
+		workload.EvictedPod = name
+		workload.Message = ""
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// deploymentPodsReady reports whether evicted is gone from pods and enough of the remaining pods
+// are ready to serve all replicas of deployment.
+func deploymentPodsReady(deployment *appsv1.Deployment, pods []corev1.Pod, evicted string) bool {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	ready := int32(0)
+	for _, pod := range pods {
+		if pod.Name == evicted {
+			return false
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				ready++
+			}
+		}
+	}
+	return ready >= replicas
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// cleanupFinalizer delays the deletion of a RollingUpdate until its restart annotations were
+// removed from the metadata of the deployments and its in-progress rollout was cancelled.
+const cleanupFinalizer = "flipper.example.com/cleanup"
+
+// finalizeRollingUpdate cancels the in-progress rollout of the deleted rollingUpdate, removes
+// its restart annotations from the metadata of the deployments and finally removes its
+// finalizer.
+func (r *RollingUpdateReconciler) finalizeRollingUpdate(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) error {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+
+	if !controllerutil.ContainsFinalizer(rollingUpdate, cleanupFinalizer) {
+		return nil
+	}
+
+	if rolloutInProgress(rollingUpdate) {
+		log.Info("Cancelling in-progress rollout of deleted RollingUpdate")
+		if err := r.deleteHookJobs(ctx, rollingUpdate); err != nil {
+			return err
+		}
+		r.Recorder.Event(rollingUpdate, corev1.EventTypeNormal, "RolloutCancelled",
+			"Cancelled the in-progress rollout, the RollingUpdate is being deleted")
+	}
+
+	if err := r.cleanupAnnotations(ctx, rollingUpdate); err != nil {
+		return err
+	}
+
+	controllerutil.RemoveFinalizer(rollingUpdate, cleanupFinalizer)
+	if err := r.Update(ctx, rollingUpdate); err != nil {
+		return fmt.Errorf("failed to remove finalizer from RollingUpdate %s/%s: %v", rollingUpdate.Namespace, rollingUpdate.Name, err)
+	}
+	log.Info("Successfully finalized RollingUpdate")
+	return nil
+}
+
+// deleteHookJobs deletes the hook Jobs of rollingUpdate that are still running, together with
+// their pods.
+func (r *RollingUpdateReconciler) deleteHookJobs(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) error {
+	jobs := &batchv1.JobList{}
+	err := r.List(ctx, jobs, client.InNamespace(rollingUpdate.Namespace), client.MatchingLabels{rollingUpdateLabel: rollingUpdate.Name})
+	if err != nil {
+		return fmt.Errorf("failed to list hook Jobs in namespace %s: %v", rollingUpdate.Namespace, err)
+	}
+
+	for _, job := range jobs.Items {
+		if job.Status.CompletionTime != nil || job.DeletionTimestamp != nil {
+			continue
+		}
+		err := r.Delete(ctx, &job, client.PropagationPolicy("Background"))
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Job %s/%s: %v", job.Namespace, job.Name, err)
+		}
+		r.Log.Info("Deleted running hook job", "namespace", job.Namespace, "job", job.Name)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// maxRolloutHistory is the number of rollouts kept in the status.
+const maxRolloutHistory = 10
+
+const (
+	rolloutReasonScheduled      = "Scheduled"
+	rolloutReasonTriggerChanged = "TriggerChanged"
+	rolloutReasonCondition      = "Condition"
+	rolloutReasonManual         = "Manual"
+)
+
+// recordRollout adds the rollout just started by rollingUpdate for reason to its history,
+// dropping the oldest rollouts beyond maxRolloutHistory.
+func recordRollout(rollingUpdate *flipperv1beta1.RollingUpdate, reason string) {
+	rollingUpdate.Status.History = append(rollingUpdate.Status.History, flipperv1beta1.RolloutRecord{
+		CycleID:   rollingUpdate.Status.CycleID,
+		StartTime: metav1.NewTime(rolloutStartTime(rollingUpdate)),
+		Reason:    reason,
+		Targets:   workloadTargets(rollingUpdate.Status.Workloads),
+	})
+	if extra := len(rollingUpdate.Status.History) - maxRolloutHistory; extra > 0 {
+		rollingUpdate.Status.History = rollingUpdate.Status.History[extra:]
+	}
+}
+
+// completeRollout records the result of the latest rollout of rollingUpdate in its history once
+// the rollout is no longer in progress.
+func completeRollout(rollingUpdate *flipperv1beta1.RollingUpdate) {
+	history := rollingUpdate.Status.History
+	if rolloutInProgress(rollingUpdate) || len(history) == 0 {
+		return
+	}
+	record := &history[len(history)-1]
+	if record.CompletionTime != nil || record.CycleID != cycleID(rollingUpdate) {
+		return
+	}
+
+	now := metav1.Now()
+	record.CompletionTime = &now
+	record.Result = rolloutResult(rollingUpdate)
+}
+
+// rolloutResult returns the result of the completed latest rollout of rollingUpdate.
+func rolloutResult(rollingUpdate *flipperv1beta1.RollingUpdate) flipperv1beta1.RolloutResult {
+	if hook := rollingUpdate.Status.PostRestartHook; hook != nil && hook.Phase == flipperv1beta1.HookPhaseFailed {
+		return flipperv1beta1.RolloutResultFailed
+	}
+	result := flipperv1beta1.RolloutResultSucceeded
+	for _, workload := range rollingUpdate.Status.Workloads {
+		switch workload.Phase {
+		case flipperv1beta1.WorkloadPhaseFailed, flipperv1beta1.WorkloadPhaseRolledBack:
+			return flipperv1beta1.RolloutResultFailed
+		case flipperv1beta1.WorkloadPhaseAborted:
+			result = flipperv1beta1.RolloutResultAborted
+		}
+	}
+	if hook := rollingUpdate.Status.PreRestartHook; hook != nil && hook.Phase == flipperv1beta1.HookPhaseFailed {
+		result = flipperv1beta1.RolloutResultAborted
+	}
+	return result
+}
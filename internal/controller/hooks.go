@@ -0,0 +1,177 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+const (
+	hookPreRestart  = "pre"
+	hookPostRestart = "post"
+
+	// defaultHookTimeout is used when a hook has no valid timeout.
+	defaultHookTimeout = 10 * time.Minute
+
+	// defaultHookJobTTL is how long finished hook Jobs are kept when their template sets no
+	// TTL. Their result is recorded long before, since running hooks are checked every
+	// rolloutRequeueInterval.
+	defaultHookJobTTL = time.Hour
+
+	rollingUpdateLabel = "flipper.example.com/rollingupdate"
+	hookLabel          = "flipper.example.com/hook"
+)
+
+// hookOf returns the hook of the given kind configured for rollingUpdate with the given scope,
+// or nil if there is none.
+func hookOf(rollingUpdate *flipperv1beta1.RollingUpdate, kind string, scope flipperv1beta1.HookScope) *flipperv1beta1.HookSpec {
+	hooks := rollingUpdate.Spec.Hooks
+	if hooks == nil {
+		return nil
+	}
+	hook := hooks.PreRestart
+	if kind == hookPostRestart {
+		hook = hooks.PostRestart
+	}
+	if hook == nil {
+		return nil
+	}
+	hookScope := hook.Scope
+	if hookScope == "" {
+		hookScope = flipperv1beta1.HookScopeWorkload
+	}
+	if hookScope != scope {
+		return nil
+	}
+	return hook
+}
+
+// createHookJob creates the Job of hook for the current rollout of rollingUpdate and returns its
+// name. deployment is the name of the restarted deployment for workload scoped hooks, and empty
+// for cycle scoped hooks. The Job name is derived from the rollout, so creating the Job of the
+// same hook twice is a no-op. The Job is created with the client of targetClient, so the
+// service account of rollingUpdate decides whether it may run the pods of the hook, and is
+// deleted by Kubernetes once its TTL after finishing expired.
+func (r *RollingUpdateReconciler) createHookJob(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, hook *flipperv1beta1.HookSpec, kind string, deployment string) (string, error) {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+
+	timeout := durationOrDefault(hook.Timeout, defaultHookTimeout)
+
+	job := &batchv1.Job{
+		ObjectMeta: *hook.Template.ObjectMeta.DeepCopy(),
+		Spec:       *hook.Template.Spec.DeepCopy(),
+	}
+	job.Name = hookJobName(rollingUpdate, kind, deployment)
+	job.Namespace = rollingUpdate.Namespace
+	if job.Labels == nil {
+		job.Labels = map[string]string{}
+	}
+	job.Labels[rollingUpdateLabel] = rollingUpdate.Name
+	job.Labels[hookLabel] = kind
+	if job.Spec.ActiveDeadlineSeconds == nil {
+		seconds := int64(timeout.Seconds())
+		job.Spec.ActiveDeadlineSeconds = &seconds
+	}
+	if job.Spec.TTLSecondsAfterFinished == nil {
+		seconds := int32(defaultHookJobTTL.Seconds())
+		job.Spec.TTLSecondsAfterFinished = &seconds
+	}
+	if job.Spec.Template.Spec.RestartPolicy == "" {
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "FLIPPER_HOOK", Value: kind},
+		{Name: "FLIPPER_ROLLINGUPDATE", Value: rollingUpdate.Name},
+	}
+	if deployment != "" {
+		env = append(env, corev1.EnvVar{Name: "FLIPPER_DEPLOYMENT", Value: deployment})
+	}
+	for i := range job.Spec.Template.Spec.Containers {
+		job.Spec.Template.Spec.Containers[i].Env = append(job.Spec.Template.Spec.Containers[i].Env, env...)
+	}
+
+	if err := controllerutil.SetControllerReference(rollingUpdate, job, r.Scheme); err != nil {
+		return "", fmt.Errorf("failed to set owner reference on Job %s/%s: %v", job.Namespace, job.Name, err)
+	}
+
+	c, err := r.targetClient(rollingUpdate)
+	if err != nil {
+		return "", err
+	}
+	err = c.Create(ctx, job)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		log.Error(err, "Failed to create hook job", "job", job.Name)
+		return "", fmt.Errorf("failed to create Job %s/%s: %v", job.Namespace, job.Name, err)
+	}
+
+	log.Info("Created hook job", "job", job.Name, "hook", kind, "deployment", deployment)
+	return job.Name, nil
+}
+
+// hookJobResult returns the phase of the hook Job name in namespace and, if it failed, a
+// description of the failure.
+func (r *RollingUpdateReconciler) hookJobResult(ctx context.Context, namespace string, name string) (flipperv1beta1.HookPhase, string, error) {
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, job)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return flipperv1beta1.HookPhaseFailed, fmt.Sprintf("hook job %s no longer exists", name), nil
+		}
+		return "", "", fmt.Errorf("failed to get Job %s/%s: %v", namespace, name, err)
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return flipperv1beta1.HookPhaseSucceeded, "", nil
+		case batchv1.JobFailed:
+			return flipperv1beta1.HookPhaseFailed, fmt.Sprintf("hook job %s failed: %s", name, condition.Reason), nil
+		}
+	}
+	return flipperv1beta1.HookPhaseRunning, "", nil
+}
+
+// hookJobName returns a name for the hook Job that is unique per rollout, hook kind and
+// deployment and short enough to be used as a label value.
+func hookJobName(rollingUpdate *flipperv1beta1.RollingUpdate, kind string, deployment string) string {
+	cycle := strconv.FormatInt(rolloutStartTime(rollingUpdate).Unix(), 10)
+	hash := sha256.Sum256([]byte(strings.Join([]string{rollingUpdate.Name, kind, deployment, cycle}, "/")))
+
+	prefix := rollingUpdate.Name
+	if len(prefix) > 40 {
+		prefix = strings.TrimRight(prefix[:40], "-.")
+	}
+	return fmt.Sprintf("%s-%s-%s", prefix, kind, hex.EncodeToString(hash[:])[:10])
+}
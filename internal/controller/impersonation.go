@@ -0,0 +1,61 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=impersonate
+
+// targetClient returns the client that accesses the deployments targeted by rollingUpdate. If
+// rollingUpdate names a service account, the client impersonates it, so the RBAC of the service
+// account decides which deployments the RollingUpdate may list and restart. Otherwise the client
+// of the operator is returned. Impersonating clients read from the API server, not the cache,
+// and are reused across reconciles.
+func (r *RollingUpdateReconciler) targetClient(rollingUpdate *flipperv1beta1.RollingUpdate) (client.Client, error) {
+	if rollingUpdate.Spec.ServiceAccountName == "" {
+		return r.Client, nil
+	}
+	username := fmt.Sprintf("system:serviceaccount:%s:%s", rollingUpdate.Namespace, rollingUpdate.Spec.ServiceAccountName)
+	if r.Config == nil {
+		return nil, fmt.Errorf("cannot impersonate %s, no REST config is configured", username)
+	}
+
+	r.impersonatingMu.Lock()
+	defer r.impersonatingMu.Unlock()
+	if c, ok := r.impersonatingClients[username]; ok {
+		return c, nil
+	}
+
+	config := rest.CopyConfig(r.Config)
+	config.Impersonate = rest.ImpersonationConfig{UserName: username}
+	c, err := client.New(config, client.Options{Scheme: r.Scheme, Mapper: r.RESTMapper()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client impersonating %s: %v", username, err)
+	}
+	if r.impersonatingClients == nil {
+		r.impersonatingClients = map[string]client.Client{}
+	}
+	r.impersonatingClients[username] = c
+	return c, nil
+}
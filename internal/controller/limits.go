@@ -0,0 +1,92 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// concurrencyRequeueInterval is how long a rollout deferred by the limit of concurrent rollouts
+// waits before the number of rollouts in progress is checked again.
+const concurrencyRequeueInterval = 30 * time.Second
+
+// checkRolloutLimits reports whether the operator config prevents a rollout of rollingUpdate from
+// starting at now, because of an active freeze, the minimum interval of the policy or the limit
+// of concurrent rollouts. If so, the reason is reported through an event and the time to wait
+// before trying again is returned.
+func (r *RollingUpdateReconciler) checkRolloutLimits(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, now time.Time) (time.Duration, error) {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+	operatorConfig := r.OperatorConfig.Get()
+
+	if freeze := operatorConfig.ActiveFreeze(rollingUpdate.Namespace, now); freeze != nil {
+		log.Info("Deferring rollout during freeze", "freeze", freeze.Name, "end", freeze.End)
+		r.Recorder.Eventf(rollingUpdate, corev1.EventTypeNormal, "RolloutDeferred",
+			"Rollout deferred until %s by freeze %s", freeze.End.UTC().Format(time.RFC3339), freeze.Name)
+		return freeze.End.Sub(now), nil
+	}
+
+	// The policy only checks the interval of the schedule, rollouts started for other reasons are
+	// spaced by the minimum interval here.
+	if rolloutPolicy := operatorConfig.Policy; rolloutPolicy != nil {
+		if minInterval := rolloutPolicy.For(rollingUpdate.Namespace).MinInterval; minInterval != nil {
+			if last := rolloutStartTime(rollingUpdate); !last.IsZero() {
+				if wait := last.Add(minInterval.Duration).Sub(now); wait > 0 {
+					log.Info("Deferring rollout, the minimum interval of the policy has not elapsed", "lastRollout", last, "minInterval", minInterval.Duration)
+					r.Recorder.Eventf(rollingUpdate, corev1.EventTypeNormal, "RolloutDeferred",
+						"Rollout deferred until %s by the minimum interval %s of the policy", last.Add(minInterval.Duration).UTC().Format(time.RFC3339), minInterval.Duration)
+					return wait, nil
+				}
+			}
+		}
+	}
+
+	if limit := operatorConfig.MaxConcurrentRollouts; limit != nil {
+		inProgress, err := r.countRolloutsInProgress(ctx, rollingUpdate)
+		if err != nil {
+			return 0, err
+		}
+		if inProgress >= int(*limit) {
+			log.Info("Deferring rollout, too many rollouts in progress", "inProgress", inProgress, "maxConcurrentRollouts", *limit)
+			r.Recorder.Eventf(rollingUpdate, corev1.EventTypeNormal, "RolloutDeferred",
+				"Rollout deferred, the limit of %d concurrent rollouts is reached", *limit)
+			return concurrencyRequeueInterval, nil
+		}
+	}
+	return 0, nil
+}
+
+// countRolloutsInProgress returns the number of RollingUpdates other than rollingUpdate with a
+// rollout in progress.
+func (r *RollingUpdateReconciler) countRolloutsInProgress(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) (int, error) {
+	rollingUpdates := &flipperv1beta1.RollingUpdateList{}
+	if err := r.List(ctx, rollingUpdates); err != nil {
+		return 0, fmt.Errorf("failed to list RollingUpdates: %v", err)
+	}
+	count := 0
+	for _, other := range rollingUpdates.Items {
+		if other.UID != rollingUpdate.UID && rolloutInProgress(&other) {
+			count++
+		}
+	}
+	return count, nil
+}
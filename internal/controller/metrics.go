@@ -0,0 +1,160 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+const (
+	// metricsCheckInterval is how often the metrics trigger is evaluated.
+	metricsCheckInterval = time.Minute
+
+	// defaultMetricsCooldown is used when the metrics trigger has no valid cooldown.
+	defaultMetricsCooldown = time.Hour
+
+	// metricsQueryTimeout bounds a single query against the Prometheus API.
+	metricsQueryTimeout = 10 * time.Second
+)
+
+// checkMetrics evaluates the metrics trigger of rollingUpdate for each targeted deployment
+// that is not cooling down, and returns the deployments for which the condition holds. Failed
+// queries are reported through events and do not stop the other checks.
+func (r *RollingUpdateReconciler) checkMetrics(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) ([]restartCondition, error) {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+	trigger := rollingUpdate.Spec.Metrics
+
+	// The URL is checked by the policy too, this guards against the config changing since.
+	if !r.OperatorConfig.Get().MetricsURLAllowed(trigger.URL) {
+		log.Info("Metrics URL is not allowed by the operator config, skipping the metrics trigger", "url", trigger.URL)
+		return nil, nil
+	}
+
+	cooldown := durationOrDefault(trigger.Cooldown, defaultMetricsCooldown)
+
+	deployments, err := r.listDeployments(ctx, rollingUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []restartCondition{}
+	for _, deployment := range deployments {
+		if last, ok := rollingUpdate.Status.MetricsTriggeredAt[deployment.Name]; ok && time.Since(last.Time) < cooldown {
+			log.V(1).Info("Skipping metrics check, deployment is cooling down", "deployment", deployment.Name, "lastTriggered", last)
+			continue
+		}
+
+		query := strings.NewReplacer("$namespace", rollingUpdate.Namespace, "$deployment", deployment.Name).Replace(trigger.Query)
+		holds, err := r.queryPrometheus(ctx, trigger.URL, query)
+		if err != nil {
+			log.Error(err, "Failed to query Prometheus", "deployment", deployment.Name, "query", query)
+			r.Recorder.Eventf(rollingUpdate, corev1.EventTypeWarning, "MetricsQueryFailed",
+				"Failed to evaluate metrics query for deployment %s: %v", deployment.Name, err)
+			continue
+		}
+		if !holds {
+			continue
+		}
+
+		log.V(1).Info("Metrics condition holds", "deployment", deployment.Name, "query", query)
+		conditions = append(conditions, restartCondition{
+			Deployment: deployment.Name,
+			Reason:     reasonMetricsConditionMet,
+			Message:    fmt.Sprintf("metrics query %q returned a result", query),
+		})
+	}
+
+	return conditions, nil
+}
+
+// prometheusResponse is the subset of the Prometheus HTTP API query response used by the
+// metrics trigger.
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// queryPrometheus evaluates query against the Prometheus compatible HTTP API at baseURL and
+// reports whether it returned at least one sample, or a non-zero scalar.
+func (r *RollingUpdateReconciler) queryPrometheus(ctx context.Context, baseURL string, query string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, metricsQueryTimeout)
+	defer cancel()
+
+	endpoint := strings.TrimSuffix(baseURL, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	result := prometheusResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode response with status %d: %v", response.StatusCode, err)
+	}
+	if result.Status != "success" {
+		return false, fmt.Errorf("query failed with status %d: %s", response.StatusCode, result.Error)
+	}
+
+	switch result.Data.ResultType {
+	case "vector", "matrix":
+		samples := []json.RawMessage{}
+		if err := json.Unmarshal(result.Data.Result, &samples); err != nil {
+			return false, fmt.Errorf("failed to decode %s result: %v", result.Data.ResultType, err)
+		}
+		return len(samples) > 0, nil
+	case "scalar":
+		// A scalar is encoded as [<timestamp>, "<value>"].
+		sample := []interface{}{}
+		if err := json.Unmarshal(result.Data.Result, &sample); err != nil || len(sample) != 2 {
+			return false, fmt.Errorf("failed to decode scalar result: %s", result.Data.Result)
+		}
+		value, ok := sample[1].(string)
+		if !ok {
+			return false, fmt.Errorf("failed to decode scalar result: %s", result.Data.Result)
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse scalar value %q: %v", value, err)
+		}
+		return number != 0, nil
+	}
+	return false, fmt.Errorf("unsupported result type %q", result.Data.ResultType)
+}
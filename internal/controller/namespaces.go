@@ -0,0 +1,81 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// watchNamespaces returns the namespaces the operator is restricted to, or nil if it watches all
+// namespaces. They match the namespaces of the manager cache, since reloading the config keeps
+// the namespaces the operator started with.
+func (r *RollingUpdateReconciler) watchNamespaces() []string {
+	return r.OperatorConfig.Get().WatchNamespaces
+}
+
+// watchesNamespace reports whether the operator manages the RollingUpdates of namespace.
+func (r *RollingUpdateReconciler) watchesNamespace(namespace string) bool {
+	namespaces := r.watchNamespaces()
+	return len(namespaces) == 0 || slices.Contains(namespaces, namespace)
+}
+
+// refuseRollingUpdate marks rollingUpdate, which lives outside the watched namespaces, as not
+// ready. Its deployments are outside the cache and the RBAC of the operator, so nothing is
+// restarted. A deleted rollingUpdate only loses its finalizer, which it may still carry from a
+// time its namespace was watched.
+func (r *RollingUpdateReconciler) refuseRollingUpdate(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) error {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+
+	if !rollingUpdate.DeletionTimestamp.IsZero() {
+		if controllerutil.RemoveFinalizer(rollingUpdate, cleanupFinalizer) {
+			if err := r.Update(ctx, rollingUpdate); err != nil {
+				return fmt.Errorf("failed to remove finalizer from RollingUpdate %s/%s: %v", rollingUpdate.Namespace, rollingUpdate.Name, err)
+			}
+		}
+		return nil
+	}
+
+	original := rollingUpdate.DeepCopy()
+	changed := meta.SetStatusCondition(&rollingUpdate.Status.Conditions, metav1.Condition{
+		Type:               flipperv1beta1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: rollingUpdate.Generation,
+		Reason:             "NamespaceNotWatched",
+		Message: fmt.Sprintf("Namespace %s is not watched by the operator, which only manages RollingUpdates in the namespaces %s",
+			rollingUpdate.Namespace, strings.Join(r.watchNamespaces(), ", ")),
+	})
+	if !changed && rollingUpdate.Status.NextRolloutTime == nil {
+		return nil
+	}
+	rollingUpdate.Status.NextRolloutTime = nil
+
+	log.Info("Refusing RollingUpdate outside the watched namespaces", "watchNamespaces", r.watchNamespaces())
+	if err := r.Status().Patch(ctx, rollingUpdate, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to update status of RollingUpdate %s/%s: %v", rollingUpdate.Namespace, rollingUpdate.Name, err)
+	}
+	return nil
+}
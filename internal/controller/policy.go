@@ -0,0 +1,67 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// policyRequeueInterval is how long a RollingUpdate violating the policy waits before the
+// policy is checked again, as the number of deployments it selects may change.
+const policyRequeueInterval = 5 * time.Minute
+
+// checkPolicy returns the violations of the policy by rollingUpdate selecting targetCount
+// deployments, and records them in its PolicyViolated condition. A metrics trigger querying a URL
+// that is not one of the metricsURLs of the operator config is a violation too. The condition is
+// removed if no policy is configured and the metrics trigger is allowed. It returns whether the
+// condition changed.
+func (r *RollingUpdateReconciler) checkPolicy(rollingUpdate *flipperv1beta1.RollingUpdate, targetCount int) ([]string, bool) {
+	operatorConfig := r.OperatorConfig.Get()
+	violations := []string{}
+	if metrics := rollingUpdate.Spec.Metrics; metrics != nil && !operatorConfig.MetricsURLAllowed(metrics.URL) {
+		violations = append(violations, fmt.Sprintf("metrics URL %q is not one of the metricsURLs of the operator", metrics.URL))
+	}
+	if operatorConfig.Policy == nil && len(violations) == 0 {
+		return nil, meta.RemoveStatusCondition(&rollingUpdate.Status.Conditions, flipperv1beta1.ConditionPolicyViolated)
+	}
+	if operatorConfig.Policy != nil {
+		rules := operatorConfig.Policy.For(rollingUpdate.Namespace)
+		violations = append(violations, rules.Validate(string(flipperv1beta1.TargetKindDeployment), rollingUpdate.Spec.Selector, r.rolloutInterval(rollingUpdate))...)
+		violations = append(violations, rules.ValidateTargets(targetCount)...)
+	}
+
+	condition := metav1.Condition{
+		Type:               flipperv1beta1.ConditionPolicyViolated,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: rollingUpdate.Generation,
+		Reason:             "PolicyCompliant",
+		Message:            "The RollingUpdate complies with the policy of the operator",
+	}
+	if len(violations) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "RulesViolated"
+		condition.Message = "The policy of the operator is violated: " + strings.Join(violations, "; ")
+	}
+	return violations, meta.SetStatusCondition(&rollingUpdate.Status.Conditions, condition)
+}
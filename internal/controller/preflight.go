@@ -0,0 +1,141 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// preflightRequeueInterval is how long a rollout deferred by a failed pre-flight check waits
+// before the checks are evaluated again.
+const preflightRequeueInterval = time.Minute
+
+// preflightFailure describes the first pre-flight check that did not pass.
+type preflightFailure struct {
+	Reason  string
+	Message string
+}
+
+// runPreflightChecks evaluates the pre-flight checks enabled for rollingUpdate against the cluster
+// state. It returns nil if all checks passed, or a description of the first failed check
+// otherwise. The pods of the namespace are listed with the client of targetClient; nodes are
+// cluster-scoped and listed with the client of the operator.
+func (r *RollingUpdateReconciler) runPreflightChecks(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, deployments []appsv1.Deployment) (*preflightFailure, error) {
+	namespace := rollingUpdate.Namespace
+	spec := rollingUpdate.Spec.Preflight
+	log := r.Log.WithValues("namespace", namespace)
+
+	if spec.RequireDeploymentsAvailable {
+		for _, deployment := range deployments {
+			if !isDeploymentAvailable(&deployment) {
+				log.V(1).Info("Pre-flight check failed, deployment is not available", "name", deployment.Name)
+				return &preflightFailure{
+					Reason:  "DeploymentUnavailable",
+					Message: fmt.Sprintf("Deployment %s is not available", deployment.Name),
+				}, nil
+			}
+		}
+	}
+
+	if spec.RequireNoUnschedulablePods {
+		c, err := r.targetClient(rollingUpdate)
+		if err != nil {
+			return nil, err
+		}
+		pods := &corev1.PodList{}
+		if err := c.List(ctx, pods, client.InNamespace(namespace)); err != nil {
+			return nil, fmt.Errorf("failed to list pods in namespace %s: %v", namespace, err)
+		}
+		for _, pod := range pods.Items {
+			if isPodUnschedulable(&pod) {
+				log.V(1).Info("Pre-flight check failed, pod is unschedulable", "pod", pod.Name)
+				return &preflightFailure{
+					Reason:  "UnschedulablePods",
+					Message: fmt.Sprintf("Pod %s is pending because it cannot be scheduled", pod.Name),
+				}, nil
+			}
+		}
+	}
+
+	if spec.MinReadyNodesPercent != nil {
+		nodes := &corev1.NodeList{}
+		if err := r.List(ctx, nodes); err != nil {
+			return nil, fmt.Errorf("failed to list nodes: %v", err)
+		}
+		ready := 0
+		for _, node := range nodes.Items {
+			if isNodeReady(&node) {
+				ready++
+			}
+		}
+		percent := 0
+		if len(nodes.Items) > 0 {
+			percent = ready * 100 / len(nodes.Items)
+		}
+		if percent < int(*spec.MinReadyNodesPercent) {
+			log.V(1).Info("Pre-flight check failed, not enough ready nodes", "ready", ready, "total", len(nodes.Items))
+			return &preflightFailure{
+				Reason: "InsufficientReadyNodes",
+				Message: fmt.Sprintf("%d of %d nodes (%d%%) are ready, at least %d%% required",
+					ready, len(nodes.Items), percent, *spec.MinReadyNodesPercent),
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func isDeploymentAvailable(deployment *appsv1.Deployment) bool {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentAvailable {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func isPodUnschedulable(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodPending {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled {
+			return condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable
+		}
+	}
+	return false
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -19,31 +19,85 @@ package controller
 import (
 	"context"
 	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/go-logr/logr"
-	flipperv1alpha1 "github.com/sigsegv1989/flipper-operator/api/v1alpha1"
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+	"github.com/sigsegv1989/flipper-operator/internal/config"
+	"github.com/sigsegv1989/flipper-operator/internal/targets"
+)
+
+const (
+	restartedAtAnnotation     = "kubectl.kubernetes.io/restartedAt"
+	restartedByAnnotation     = "kubectl.kubernetes.io/restartedBy"
+	restartedByCRAnnotation   = "flipper.example.com/restartedByCR"
+	restartedByKindAnnotation = "flipper.example.com/restartedByCRDKind"
 )
 
 // RollingUpdateReconciler reconciles a RollingUpdate object
 type RollingUpdateReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// HTTPClient is used for the verification checks. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// APIReader reads from the API server instead of the cache. The data of trigger Secrets and
+	// ConfigMaps is read with it, as only their metadata is cached. If nil, the client is used.
+	APIReader client.Reader
+
+	// OperatorConfig holds the config of the operator, which may be reloaded at any time: its
+	// field manager, policy, default interval, annotation prefix, freezes and rollout limit. Its
+	// watched namespaces are those of the manager cache, they are kept on reload. If nil, the
+	// defaults are used.
+	OperatorConfig *config.Store
+
+	// Config is the REST config used to build the clients impersonating the service accounts
+	// named by RollingUpdates. If nil, RollingUpdates naming a service account fail to reconcile.
+	Config *rest.Config
+
+	impersonatingMu      sync.Mutex
+	impersonatingClients map[string]client.Client
 }
 
 // +kubebuilder:rbac:groups=flipper.example.com,resources=rollingupdates,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=flipper.example.com,resources=rollingupdates/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=flipper.example.com,resources=rollingupdates/finalizers,verbs=update
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods/eviction,verbs=create
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -58,7 +112,7 @@ func (r *RollingUpdateReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	log := r.Log.WithValues("rollingupdate", req.NamespacedName)
 
 	// Fetch the RollingUpdate CR instance
-	rollingUpdate := &flipperv1alpha1.RollingUpdate{}
+	rollingUpdate := &flipperv1beta1.RollingUpdate{}
 	err := r.Get(ctx, req.NamespacedName, rollingUpdate)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -70,87 +124,546 @@ func (r *RollingUpdateReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 	log.V(1).Info("Successfully retrieved RollingUpdate resource", "rollingUpdate", rollingUpdate)
 
-	interval, err := time.ParseDuration(rollingUpdate.Spec.Interval)
+	if !r.watchesNamespace(req.Namespace) {
+		if err := r.refuseRollingUpdate(ctx, rollingUpdate); err != nil {
+			log.Error(err, "Failed to refuse RollingUpdate outside the watched namespaces")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !rollingUpdate.DeletionTimestamp.IsZero() {
+		if err := r.finalizeRollingUpdate(ctx, rollingUpdate); err != nil {
+			log.Error(err, "Failed to finalize RollingUpdate")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if controllerutil.AddFinalizer(rollingUpdate, cleanupFinalizer) {
+		if err := r.Update(ctx, rollingUpdate); err != nil {
+			log.Error(err, "Failed to add finalizer to RollingUpdate")
+			return ctrl.Result{}, err
+		}
+	}
+
+	interval := r.rolloutInterval(rollingUpdate)
+	log.V(1).Info("Successfully retrieved RollingUpdate interval", "interval", interval)
+
+	targets, err := r.listDeployments(ctx, rollingUpdate)
 	if err != nil {
-		log.Error(err, "Failed to parse interval duration", "interval", rollingUpdate.Spec.Interval)
+		log.Error(err, "Failed to list target deployments")
+		if errors.IsForbidden(err) && rollingUpdate.Spec.ServiceAccountName != "" {
+			r.Recorder.Eventf(rollingUpdate, corev1.EventTypeWarning, "Forbidden",
+				"Service account %s may not list the targeted deployments: %v", rollingUpdate.Spec.ServiceAccountName, err)
+		}
 		return ctrl.Result{}, err
 	}
-	log.V(1).Info("Successfully retrieved RollingUpdate interval", "interval", interval)
+	targetCount := int32(len(targets))
+	targetCountChanged := rollingUpdate.Status.TargetCount != targetCount
+	rollingUpdate.Status.TargetCount = targetCount
+	violations, policyChanged := r.checkPolicy(rollingUpdate, len(targets))
 
-	now := time.Now()
-	if rollingUpdate.Status.LastRolloutTime.Time.IsZero() ||
-		now.Sub(rollingUpdate.Status.LastRolloutTime.Time) > interval {
-		log.V(1).Info("Time to rolling restart resources", "lastRolloutTime", rollingUpdate.Status.LastRolloutTime, "now", now, "interval", interval)
+	if rolloutInProgress(rollingUpdate) {
+		log.V(1).Info("Checking progress of restarted deployments", "workloads", rollingUpdate.Status.Workloads)
 
-		deployments, err := r.restartDeployments(ctx, req, rollingUpdate.Spec.MatchLabels)
+		err = r.checkRollouts(ctx, req, rollingUpdate)
 		if err != nil {
-			log.Error(err, "Failed to restart deployments")
+			log.Error(err, "Failed to check rollout progress")
 			return ctrl.Result{}, err
 		}
+		completeRollout(rollingUpdate)
 
-		rollingUpdate.Status.LastRolloutTime = metav1.Now()
-		rollingUpdate.Status.Deployments = deployments
-		err = r.Status().Update(ctx, rollingUpdate)
+		err = r.updateStatus(ctx, rollingUpdate)
 		if err != nil {
 			log.Error(err, "Failed to update rollingUpdate status")
 			return ctrl.Result{}, err
 		}
 
-		log.Info("Successfully rolling restarted resource and updated RollingUpdate status", "lastRolloutTime", rollingUpdate.Status.LastRolloutTime)
+		if rolloutInProgress(rollingUpdate) {
+			return ctrl.Result{RequeueAfter: rolloutRequeueInterval}, nil
+		}
+	}
+
+	// Resuming the RollingUpdate changes its spec, which triggers a new reconcile.
+	if rollingUpdate.Spec.Suspend {
+		log.V(1).Info("RollingUpdate is suspended, not starting rollouts")
+		if rollingUpdate.Status.NextRolloutTime != nil || targetCountChanged || policyChanged || setReadyCondition(rollingUpdate) {
+			rollingUpdate.Status.NextRolloutTime = nil
+			if err := r.updateStatus(ctx, rollingUpdate); err != nil {
+				log.Error(err, "Failed to update rollingUpdate status")
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Changing the RollingUpdate to comply with the policy triggers a new reconcile, changes of
+	// the selected deployments are picked up on the next requeue.
+	if len(violations) > 0 {
+		log.Info("RollingUpdate violates the policy, not starting rollouts", "violations", violations)
+		if policyChanged {
+			r.Recorder.Eventf(rollingUpdate, corev1.EventTypeWarning, flipperv1beta1.ConditionPolicyViolated,
+				"Not starting rollouts: %s", strings.Join(violations, "; "))
+		}
+		if rollingUpdate.Status.NextRolloutTime != nil || targetCountChanged || policyChanged || setReadyCondition(rollingUpdate) {
+			rollingUpdate.Status.NextRolloutTime = nil
+			if err := r.updateStatus(ctx, rollingUpdate); err != nil {
+				log.Error(err, "Failed to update rollingUpdate status")
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{RequeueAfter: policyRequeueInterval}, nil
+	}
+
+	triggers, err := r.checkTriggers(ctx, rollingUpdate)
+	if err != nil {
+		log.Error(err, "Failed to check trigger objects")
+		return ctrl.Result{}, err
+	}
+
+	// With thresholds, the interval no longer restarts the targets.
+	if rollingUpdate.Spec.Thresholds != nil {
+		exceeded, err := r.checkThresholds(ctx, rollingUpdate)
+		if err != nil {
+			log.Error(err, "Failed to check restart thresholds")
+			return ctrl.Result{}, err
+		}
+		triggers.conditions = append(triggers.conditions, exceeded...)
+	}
+	if rollingUpdate.Spec.Metrics != nil {
+		met, err := r.checkMetrics(ctx, rollingUpdate)
+		if err != nil {
+			log.Error(err, "Failed to check metrics trigger")
+			return ctrl.Result{}, err
+		}
+		triggers.conditions = append(triggers.conditions, met...)
+	}
+
+	now := time.Now()
+	missed := rollingUpdate.Status.MissedSchedule
+	requested := rollingUpdate.Annotations[flipperv1beta1.TriggerAnnotation]
+	manual := requested != "" && requested != rollingUpdate.Status.LastTrigger
+	due := manual || len(triggers.changed) > 0
+	var scheduled time.Time
+	if rollingUpdate.Spec.Thresholds == nil {
+		var scheduleDue bool
+		scheduleDue, scheduled = r.checkSchedule(rollingUpdate, interval, now)
+		due = due || scheduleDue
 	}
 
-	return ctrl.Result{RequeueAfter: interval}, nil
+	// A rollout that is not due restarts only the deployments affected by auto discovered
+	// objects, thresholds or metrics.
+	only := slices.Clone(triggers.affected)
+	for _, entry := range triggers.conditions {
+		if !slices.Contains(only, entry.Deployment) {
+			only = append(only, entry.Deployment)
+		}
+	}
+
+	if due || len(only) > 0 {
+		log.V(1).Info("Time to rolling restart resources", "lastRolloutTime", rollingUpdate.Status.LastRolloutTime, "now", now, "interval", interval, "changedTriggers", triggers.changed, "affectedDeployments", only)
+
+		reason := rolloutReasonScheduled
+		switch {
+		case manual:
+			reason = rolloutReasonManual
+		case len(triggers.changed) > 0:
+			reason = rolloutReasonTriggerChanged
+		case !due:
+			reason = rolloutReasonCondition
+		}
+		if due {
+			only = nil
+		}
+		wait, err := r.checkRolloutLimits(ctx, rollingUpdate, now)
+		if err != nil {
+			log.Error(err, "Failed to check rollout limits")
+			return ctrl.Result{}, err
+		}
+		if wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+		deferredBy, err := r.startRollout(ctx, req, rollingUpdate, triggers, only, reason)
+		if err != nil {
+			log.Error(err, "Failed to start rollout")
+			return ctrl.Result{}, err
+		}
+		if deferredBy != nil {
+			log.Info("Deferring rolling restart, pre-flight checks failed", "reason", deferredBy.Reason, "message", deferredBy.Message)
+			return ctrl.Result{RequeueAfter: preflightRequeueInterval}, nil
+		}
+
+		if manual {
+			r.Recorder.Eventf(rollingUpdate, corev1.EventTypeNormal, "RolloutTriggered",
+				"Started rollout requested through the %s annotation", flipperv1beta1.TriggerAnnotation)
+		} else if len(triggers.changed) > 0 {
+			r.Recorder.Eventf(rollingUpdate, corev1.EventTypeNormal, "RolloutTriggered",
+				"Started rollout because %s changed", strings.Join(triggers.changed, ", "))
+		} else if len(triggers.affected) > 0 {
+			r.Recorder.Eventf(rollingUpdate, corev1.EventTypeNormal, "RolloutTriggered",
+				"Started rollout of %s because consumed Secrets or ConfigMaps changed", strings.Join(triggers.affected, ", "))
+		}
+		for _, entry := range triggers.conditions {
+			r.Recorder.Eventf(rollingUpdate, corev1.EventTypeNormal, entry.Reason,
+				"Restarting deployment %s: %s", entry.Deployment, entry.Message)
+		}
+		log.Info("Successfully rolling restarted resource and updated RollingUpdate status", "lastRolloutTime", rollingUpdate.Status.LastRolloutTime, "deferred", len(rollingUpdate.Status.Deferred))
+	} else {
+		// Objects that started or stopped being selected by a trigger only update the
+		// recorded hashes, and a skipped or postponed rollout only records the decision and
+		// the next rollout time.
+		next := r.nextRolloutTime(rollingUpdate, now)
+		updateStatus := targetCountChanged || setReadyCondition(rollingUpdate) ||
+			rollingUpdate.Status.MissedSchedule != missed ||
+			!rollingUpdate.Status.NextRolloutTime.Equal(next) ||
+			!maps.Equal(rollingUpdate.Status.TriggerHashes, triggers.hashes) ||
+			!maps.EqualFunc(rollingUpdate.Status.WorkloadTriggerHashes, triggers.workloadHashes, maps.Equal[map[string]string])
+		rollingUpdate.Status.NextRolloutTime = next
+		rollingUpdate.Status.TriggerHashes = triggers.hashes
+		rollingUpdate.Status.WorkloadTriggerHashes = triggers.workloadHashes
+
+		if len(rollingUpdate.Status.Deferred) > 0 {
+			log.V(1).Info("Retrying deferred deployments", "deferred", rollingUpdate.Status.Deferred)
+
+			err = r.retryDeferredDeployments(ctx, req, rollingUpdate)
+			if err != nil {
+				log.Error(err, "Failed to restart deferred deployments")
+				return ctrl.Result{}, err
+			}
+			updateStatus = true
+		}
+
+		if updateStatus {
+			err = r.updateStatus(ctx, rollingUpdate)
+			if err != nil {
+				log.Error(err, "Failed to update rollingUpdate status")
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	if rolloutInProgress(rollingUpdate) {
+		// The recorded rollout restarts its deployments right away instead of on the next
+		// requeue.
+		err = r.checkRollouts(ctx, req, rollingUpdate)
+		if err != nil {
+			log.Error(err, "Failed to check rollout progress")
+			return ctrl.Result{}, err
+		}
+		completeRollout(rollingUpdate)
+
+		err = r.updateStatus(ctx, rollingUpdate)
+		if err != nil {
+			log.Error(err, "Failed to update rollingUpdate status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: rolloutRequeueInterval}, nil
+	}
+	requeueAfter := interval
+	if rollingUpdate.Spec.Thresholds != nil {
+		requeueAfter = thresholdCheckInterval
+	}
+	if until := time.Until(scheduled); until > 0 && until < requeueAfter {
+		requeueAfter = until
+	}
+	if rollingUpdate.Spec.Metrics != nil && metricsCheckInterval < requeueAfter {
+		requeueAfter = metricsCheckInterval
+	}
+	if len(rollingUpdate.Status.Deferred) > 0 && deferredRequeueInterval < requeueAfter {
+		return ctrl.Result{RequeueAfter: deferredRequeueInterval}, nil
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
-func (r *RollingUpdateReconciler) restartDeployments(ctx context.Context, req ctrl.Request, labels map[string]string) ([]string, error) {
-	log := r.Log.WithValues("namespace", req.Namespace, "name", req.Name)
+// startRollout records a rollout of the deployments targeted by rollingUpdate in its status
+// together with the trigger hashes. The deployments are left pending and restarted by
+// checkRollouts, and the rollout is added to the history with reason. If only is not nil, just
+// the named targets are part of the rollout: such a partial rollout does not advance the
+// schedule of the other targets, so it leaves the last and next rollout times alone. If the
+// pre-flight checks fail, no rollout is recorded and the failed check is returned.
+func (r *RollingUpdateReconciler) startRollout(ctx context.Context, req ctrl.Request, rollingUpdate *flipperv1beta1.RollingUpdate, triggers *triggerState, only []string, reason string) (*preflightFailure, error) {
+	targets, err := r.listDeployments(ctx, rollingUpdate)
+	if err != nil {
+		return nil, err
+	}
+	selected := targets
+	if only != nil {
+		targets = slices.DeleteFunc(slices.Clone(targets), func(deployment appsv1.Deployment) bool {
+			return !slices.Contains(only, deployment.Name)
+		})
+	}
 
-	log.V(1).Info("Listing deployments for rolling restart", "labels", labels)
-	log.V(1).Info("Checking deployment labels", "namespace", req.Namespace, "expectedLabels", labels)
+	if rollingUpdate.Spec.Preflight != nil {
+		failure, err := r.runPreflightChecks(ctx, rollingUpdate, targets)
+		if err != nil {
+			return nil, err
+		}
+		if failure != nil {
+			meta.SetStatusCondition(&rollingUpdate.Status.Conditions, metav1.Condition{
+				Type:               flipperv1beta1.ConditionPreflightFailed,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: rollingUpdate.Generation,
+				Reason:             failure.Reason,
+				Message:            failure.Message,
+			})
+			if err := r.updateStatus(ctx, rollingUpdate); err != nil {
+				return nil, fmt.Errorf("failed to update RollingUpdate status: %v", err)
+			}
+			return failure, nil
+		}
+		meta.SetStatusCondition(&rollingUpdate.Status.Conditions, metav1.Condition{
+			Type:               flipperv1beta1.ConditionPreflightFailed,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: rollingUpdate.Generation,
+			Reason:             "PreflightPassed",
+			Message:            "All pre-flight checks passed",
+		})
+	} else {
+		meta.RemoveStatusCondition(&rollingUpdate.Status.Conditions, flipperv1beta1.ConditionPreflightFailed)
+	}
 
-	deployments := &appsv1.DeploymentList{}
-	err := r.List(ctx, deployments, client.InNamespace(req.Namespace), client.MatchingLabels(labels))
+	targets, deferred, err := r.filterDisruptionBudgets(ctx, rollingUpdate, targets)
 	if err != nil {
-		log.Error(err, "Failed to list deployments", "labels", labels)
-		return []string{}, err
+		return nil, err
+	}
+	for _, entry := range deferred {
+		r.Recorder.Eventf(rollingUpdate, corev1.EventTypeWarning, "RestartDeferred",
+			"Restart of deployment %s deferred, PodDisruptionBudget %s allows no disruptions", entry.Name, entry.PodDisruptionBudget)
 	}
-	log.V(1).Info("Deployments listed", "deploymentCount", len(deployments.Items))
 
-	deploys := []string{}
-	for _, deployment := range deployments.Items {
-		deploys = append(deploys, deployment.Name)
-		log.V(1).Info("Restarting deployment", "namespace", deployment.Namespace, "name", deployment.Name)
+	// The rollout start time identifies the rollout, so it is set before any hook Job is created.
+	// It is truncated to the precision it is stored with, so the cycle ID derived from it does
+	// not change when the status is read back.
+	startTime := metav1.NewTime(time.Now().Truncate(time.Second))
+	rollingUpdate.Status.CycleID = startTime.UTC().Format(time.RFC3339)
+	if only == nil {
+		rollingUpdate.Status.LastRolloutTime = startTime
+		rollingUpdate.Status.NextRolloutTime = r.nextRolloutTime(rollingUpdate, time.Now())
+	}
+	rollingUpdate.Status.PreRestartHook = nil
+	rollingUpdate.Status.PostRestartHook = nil
 
-		annotations := map[string]string{
-			"kubectl.kubernetes.io/restartedAt":      time.Now().Format(time.RFC3339),
-			"kubectl.kubernetes.io/restartedBy":      "flipper-operator",
-			"flipper.example.com/restartedByCR":      fmt.Sprintf("%s/%s", req.Namespace, req.Name),
-			"flipper.example.com/restartedByCRDKind": "rollingupdate",
+	if hook := hookOf(rollingUpdate, hookPreRestart, flipperv1beta1.HookScopeCycle); hook != nil {
+		job, err := r.createHookJob(ctx, rollingUpdate, hook, hookPreRestart, "")
+		if err != nil {
+			return nil, err
+		}
+		rollingUpdate.Status.PreRestartHook = &flipperv1beta1.HookStatus{
+			JobName: job,
+			Phase:   flipperv1beta1.HookPhaseRunning,
+		}
+	}
+
+	// The rollout is recorded before any deployment is restarted, so it can be resumed if the
+	// operator stops halfway through it.
+	workloads := r.planWorkloads(rollingUpdate, targets)
+	rollingUpdate.Status.Targets = workloadTargets(workloads)
+	if only != nil {
+		rollingUpdate.Status.Targets = deploymentTargets(selected)
+	}
+	rollingUpdate.Status.Deferred = deferred
+	rollingUpdate.Status.Workloads = workloads
+	rollingUpdate.Status.TriggerHashes = triggers.hashes
+	rollingUpdate.Status.WorkloadTriggerHashes = triggers.workloadHashes
+	rollingUpdate.Status.LastTrigger = rollingUpdate.Annotations[flipperv1beta1.TriggerAnnotation]
+	recordRollout(rollingUpdate, reason)
+	completeRollout(rollingUpdate)
+	for _, entry := range triggers.conditions {
+		if entry.Reason != reasonMetricsConditionMet {
+			continue
 		}
-		r.updateAnnotations(&deployment, annotations)
+		if rollingUpdate.Status.MetricsTriggeredAt == nil {
+			rollingUpdate.Status.MetricsTriggeredAt = map[string]metav1.Time{}
+		}
+		rollingUpdate.Status.MetricsTriggeredAt[entry.Deployment] = startTime
+	}
+	meta.RemoveStatusCondition(&rollingUpdate.Status.Conditions, flipperv1beta1.ConditionRolloutFailed)
+	if err := r.updateStatus(ctx, rollingUpdate); err != nil {
+		return nil, fmt.Errorf("failed to update RollingUpdate status: %v", err)
+	}
+
+	return nil, nil
+}
 
-		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
-			return r.Update(ctx, &deployment)
+// listDeployments returns the deployments targeted by rollingUpdate, listed with the permissions
+// of its service account, if any.
+func (r *RollingUpdateReconciler) listDeployments(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) ([]appsv1.Deployment, error) {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace)
+	selector := rollingUpdate.Spec.Selector
+
+	log.V(1).Info("Listing deployments for rolling restart", "selector", selector)
+
+	c, err := r.targetClient(rollingUpdate)
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := targets.List(ctx, c, rollingUpdate.Namespace, selector)
+	if err != nil {
+		log.Error(err, "Failed to list deployments", "selector", selector)
+		return nil, err
+	}
+	log.V(1).Info("Deployments listed", "deploymentCount", len(deployments))
+
+	return deployments, nil
+}
+
+// planWorkloads returns the pending workloads of a rollout of rollingUpdate restarting
+// deployments, with the current values of their restart annotations.
+func (r *RollingUpdateReconciler) planWorkloads(rollingUpdate *flipperv1beta1.RollingUpdate, deployments []appsv1.Deployment) []flipperv1beta1.WorkloadStatus {
+	workloads := []flipperv1beta1.WorkloadStatus{}
+	for _, deployment := range deployments {
+		workloads = append(workloads, flipperv1beta1.WorkloadStatus{
+			TargetReference:     deploymentReference(deployment.Name),
+			Phase:               flipperv1beta1.WorkloadPhasePending,
+			PreviousAnnotations: r.previousAnnotations(rollingUpdate, &deployment),
 		})
+	}
+	return workloads
+}
+
+// deploymentReference returns the reference to the deployment name.
+func deploymentReference(name string) flipperv1beta1.TargetReference {
+	return flipperv1beta1.TargetReference{Kind: flipperv1beta1.TargetKindDeployment, Name: name}
+}
+
+// previousAnnotations returns the pod template values of the restart annotations of deployment.
+func (r *RollingUpdateReconciler) previousAnnotations(rollingUpdate *flipperv1beta1.RollingUpdate, deployment *appsv1.Deployment) map[string]string {
+	if rollingUpdate.Spec.Method == flipperv1beta1.RestartMethodEvictPods {
+		return nil
+	}
+	previous := map[string]string{}
+	for _, key := range r.restartAnnotationKeys(rollingUpdate) {
+		if value, ok := deployment.Spec.Template.Annotations[key]; ok {
+			previous[key] = value
+		}
+	}
+	return previous
+}
+
+// cycleID returns the ID of the current rollout of rollingUpdate. Rollouts started before the
+// ID was recorded are identified by their start time.
+func cycleID(rollingUpdate *flipperv1beta1.RollingUpdate) string {
+	if rollingUpdate.Status.CycleID != "" {
+		return rollingUpdate.Status.CycleID
+	}
+	return rollingUpdate.Status.LastRolloutTime.UTC().Format(time.RFC3339)
+}
 
+// rolloutStartTime returns when the current rollout of rollingUpdate started, which is encoded in
+// its cycle ID.
+func rolloutStartTime(rollingUpdate *flipperv1beta1.RollingUpdate) time.Time {
+	start, err := time.Parse(time.RFC3339, cycleID(rollingUpdate))
+	if err != nil {
+		return rollingUpdate.Status.LastRolloutTime.Time
+	}
+	return start
+}
+
+// durationOrDefault returns duration, or fallback if duration is not set or not positive.
+func durationOrDefault(duration *metav1.Duration, fallback time.Duration) time.Duration {
+	if duration == nil || duration.Duration <= 0 {
+		return fallback
+	}
+	return duration.Duration
+}
+
+// restartWorkload restarts deployment with the method selected by rollingUpdate and moves
+// workload to the Restarting phase.
+func (r *RollingUpdateReconciler) restartWorkload(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, workload *flipperv1beta1.WorkloadStatus, deployment *appsv1.Deployment) error {
+	if rollingUpdate.Spec.Method == flipperv1beta1.RestartMethodEvictPods {
+		return r.planEvictions(ctx, rollingUpdate, workload, deployment)
+	}
+	return r.restartDeployment(ctx, rollingUpdate, workload, deployment)
+}
+
+// restartDeployment restarts deployment by writing the restart annotations of the current
+// rollout to its pod template, and to its metadata if the placement of rollingUpdate asks for it,
+// in which case the written keys are recorded in the status. A deployment whose restartedAt annotation already holds the
+// cycle ID was restarted by this rollout before the operator stopped, and is not patched again.
+func (r *RollingUpdateReconciler) restartDeployment(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, workload *flipperv1beta1.WorkloadStatus, deployment *appsv1.Deployment) error {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+
+	restartedAt := cycleID(rollingUpdate)
+	if deployment.Spec.Template.Annotations[restartedAtAnnotation] == restartedAt {
+		log.Info("Deployment was already restarted by this rollout, resuming", "deployment", deployment.Name, "cycle", restartedAt)
+	} else {
+		log.V(1).Info("Restarting deployment", "namespace", deployment.Namespace, "name", deployment.Name)
+
+		workload.PreviousAnnotations = r.previousAnnotations(rollingUpdate, deployment)
+		original := deployment.DeepCopy()
+		r.updateAnnotations(deployment, r.restartAnnotations(rollingUpdate, restartedAt), annotateObject(rollingUpdate))
+
+		c, err := r.targetClient(rollingUpdate)
+		if err != nil {
+			return err
+		}
+		// A merge patch touches only the restart annotations, so concurrent changes to the
+		// deployment are neither clobbered nor rejected as conflicts.
+		err = c.Patch(ctx, deployment, client.MergeFrom(original), client.FieldOwner(r.fieldManager()))
 		if err != nil {
 			log.Error(err, "Failed to update deployment", "name", deployment.Name)
-			return []string{}, fmt.Errorf("failed to update Deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
+			return fmt.Errorf("failed to update Deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
 		}
-
 		log.Info("Successfully rolling restarted deployment", "name", deployment.Name)
 	}
+	if annotateObject(rollingUpdate) {
+		recordAnnotatedTarget(rollingUpdate, deployment.Name, r.restartAnnotations(rollingUpdate, restartedAt))
+	}
+
+	workload.Phase = flipperv1beta1.WorkloadPhaseRestarting
+	workload.RestartedAt = restartedAt
+	return nil
+}
+
+// updateStatus writes the status of rollingUpdate. Restarting deployments can take a while, so
+// the RollingUpdate may have changed since it was read: the status is patched onto a copy read
+// from the API server with optimistic locking, and re-applied to a new copy when the patch
+// conflicts. Concurrent changes of the spec and metadata are kept, but the status is owned by
+// the operator and replaced as a whole, so status changes made by others since rollingUpdate was
+// read are overwritten. The Ready condition is derived from the rest of the status before it is
+// written. On success, rollingUpdate is updated to the patched object.
+func (r *RollingUpdateReconciler) updateStatus(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) error {
+	setReadyCondition(rollingUpdate)
+	status := rollingUpdate.Status.DeepCopy()
 
-	return deploys, nil
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		// The cache may still hold the copy that conflicted, read it from the API server.
+		latest := &flipperv1beta1.RollingUpdate{}
+		if err := r.apiReader().Get(ctx, client.ObjectKeyFromObject(rollingUpdate), latest); err != nil {
+			return err
+		}
+
+		original := latest.DeepCopy()
+		status.DeepCopyInto(&latest.Status)
+		err := r.Status().Patch(ctx, latest, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{}))
+		if err != nil {
+			if errors.IsConflict(err) {
+				r.Log.V(1).Info("RollingUpdate changed while updating its status, retrying",
+					"namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+			}
+			return err
+		}
+
+		latest.DeepCopyInto(rollingUpdate)
+		return nil
+	})
 }
 
-func (r *RollingUpdateReconciler) updateAnnotations(deployment *appsv1.Deployment, annotations map[string]string) {
+// fieldManager returns the field manager used for patches of deployments.
+func (r *RollingUpdateReconciler) fieldManager() string {
+	return r.OperatorConfig.Get().FieldManager
+}
+
+func (r *RollingUpdateReconciler) updateAnnotations(deployment *appsv1.Deployment, annotations map[string]string, annotateObject bool) {
 	// Update the Deployment's annotations
-	if deployment.Annotations == nil {
-		deployment.Annotations = make(map[string]string)
-	}
-	for key, value := range annotations {
-		deployment.Annotations[key] = value
+	if annotateObject {
+		if deployment.Annotations == nil {
+			deployment.Annotations = make(map[string]string)
+		}
+		for key, value := range annotations {
+			deployment.Annotations[key] = value
+		}
 	}
 
 	// Update the pod template spec's annotations to trigger a rollout
@@ -166,7 +679,41 @@ func (r *RollingUpdateReconciler) updateAnnotations(deployment *appsv1.Deploymen
 func (r *RollingUpdateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Log = mgr.GetLogger().WithName("controller").WithName("RollingUpdate")
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&flipperv1alpha1.RollingUpdate{}).
-		Complete(r)
+	// Only the metadata of Secrets and ConfigMaps is cached, so the operator does not hold the
+	// data of all of them in memory. Their data is read through the APIReader.
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		For(&flipperv1beta1.RollingUpdate{}).
+		Owns(&batchv1.Job{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.rollingUpdatesForTrigger(triggerKindSecret)), builder.OnlyMetadata).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.rollingUpdatesForTrigger(triggerKindConfigMap)), builder.OnlyMetadata)
+	if r.OperatorConfig != nil {
+		// A reloaded config may change the policy, schedule or limits of any RollingUpdate.
+		controllerBuilder = controllerBuilder.WatchesRawSource(source.Channel(r.OperatorConfig.Changes(), handler.EnqueueRequestsFromMapFunc(r.allRollingUpdates)))
+	}
+	return controllerBuilder.Complete(r)
+}
+
+// apiReader returns the reader reading from the API server, or the client if there is none.
+func (r *RollingUpdateReconciler) apiReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// allRollingUpdates returns requests for all RollingUpdates, regardless of obj.
+func (r *RollingUpdateReconciler) allRollingUpdates(ctx context.Context, _ client.Object) []reconcile.Request {
+	rollingUpdates := &flipperv1beta1.RollingUpdateList{}
+	if err := r.List(ctx, rollingUpdates); err != nil {
+		r.Log.Error(err, "Failed to list RollingUpdates for reloaded config")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, rollingUpdate := range rollingUpdates.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: rollingUpdate.Namespace, Name: rollingUpdate.Name},
+		})
+	}
+	return requests
 }
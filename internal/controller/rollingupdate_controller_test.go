@@ -18,17 +18,34 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	flipperv1alpha1 "github.com/sigsegv1989/flipper-operator/api/v1alpha1"
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+	"github.com/sigsegv1989/flipper-operator/internal/config"
+	"github.com/sigsegv1989/flipper-operator/internal/policy"
 )
 
 var _ = Describe("RollingUpdate Controller", func() {
@@ -41,22 +58,26 @@ var _ = Describe("RollingUpdate Controller", func() {
 			Name:      resourceName,
 			Namespace: "default",
 		}
-		rollingupdate := &flipperv1alpha1.RollingUpdate{}
+		rollingupdate := &flipperv1beta1.RollingUpdate{}
 
 		BeforeEach(func() {
 			By("creating the custom resource for the Kind RollingUpdate")
 			err := k8sClient.Get(ctx, typeNamespacedName, rollingupdate)
 			if err != nil && errors.IsNotFound(err) {
-				resource := &flipperv1alpha1.RollingUpdate{
+				resource := &flipperv1beta1.RollingUpdate{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      resourceName,
 						Namespace: "default",
 					},
-					Spec: flipperv1alpha1.RollingUpdateSpec{
-						MatchLabels: map[string]string{
-							"key1": "value1",
+					Spec: flipperv1beta1.RollingUpdateSpec{
+						Selector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"key1": "value1",
+							},
+						},
+						Schedule: flipperv1beta1.ScheduleSpec{
+							Interval: &metav1.Duration{Duration: time.Minute},
 						},
-						Interval: "1m",
 					},
 				}
 				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
@@ -65,7 +86,7 @@ var _ = Describe("RollingUpdate Controller", func() {
 
 		AfterEach(func() {
 			// TODO(user): Cleanup logic after each test, like removing the resource instance.
-			resource := &flipperv1alpha1.RollingUpdate{}
+			resource := &flipperv1beta1.RollingUpdate{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
 			Expect(err).NotTo(HaveOccurred())
 
@@ -76,8 +97,9 @@ var _ = Describe("RollingUpdate Controller", func() {
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
 			controllerReconciler := &RollingUpdateReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
 			}
 
 			res, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
@@ -86,13 +108,13 @@ var _ = Describe("RollingUpdate Controller", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(res.RequeueAfter).To(BeNumerically("==", time.Minute))
 
-			rollingupdate := &flipperv1alpha1.RollingUpdate{}
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
 			By("getting the custom resource for the Kind RollingUpdate")
 			err = k8sClient.Get(ctx, typeNamespacedName, rollingupdate)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(rollingupdate.Status.LastRolloutTime.IsZero()).NotTo(BeTrue())
-			Expect(rollingupdate.Status.Deployments).To(BeEmpty())
+			Expect(rollingupdate.Status.Targets).To(BeEmpty())
 
 			// Allow for some time discrepancy in the comparison
 			lastRolloutTime := rollingupdate.Status.LastRolloutTime.Time
@@ -100,4 +122,2508 @@ var _ = Describe("RollingUpdate Controller", func() {
 			Expect(now.Sub(lastRolloutTime)).To(BeNumerically("<", time.Second*5))
 		})
 	})
-})
+
+	Context("When a scheduled rollout was missed", func() {
+		const resourceName = "missed-schedule-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "missed-schedule-deployment",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "missed-schedule"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "missed-schedule"}},
+					Schedule: flipperv1beta1.ScheduleSpec{
+						Interval:             &metav1.Duration{Duration: time.Hour},
+						MissedSchedulePolicy: flipperv1beta1.MissedSchedulePolicySkip,
+						StartingDeadline:     &metav1.Duration{Duration: 30 * time.Minute},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			By("Recording a rollout from three days ago")
+			resource.Status.LastRolloutTime = metav1.NewTime(time.Now().Add(-72 * time.Hour))
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should skip the missed rollout and record the decision", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).NotTo(HaveKey("kubectl.kubernetes.io/restartedAt"))
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.MissedSchedule).NotTo(BeNil())
+			Expect(rollingupdate.Status.MissedSchedule.Decision).To(Equal(flipperv1beta1.MissedScheduleSkipped))
+			Expect(rollingupdate.Status.MissedSchedule.NextTime).NotTo(BeNil())
+			Expect(rollingupdate.Status.MissedSchedule.NextTime.Time).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+		})
+	})
+
+	Context("When the operator config freezes rollouts", func() {
+		const resourceName = "frozen-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "frozen-deployment",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "frozen"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frozen"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should defer rollouts until the freeze ends and apply the default interval", func() {
+			recorder := record.NewFakeRecorder(10)
+			operatorConfig := config.NewStore(&config.FlipperConfig{
+				DefaultInterval: &metav1.Duration{Duration: 2 * time.Hour},
+				Freezes: []config.Freeze{{
+					Name:  "release",
+					Start: metav1.NewTime(time.Now().Add(-time.Hour)),
+					End:   metav1.NewTime(time.Now().Add(time.Hour)),
+				}},
+			})
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				Recorder:       recorder,
+				OperatorConfig: operatorConfig,
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+			Expect(recorder.Events).To(Receive(ContainSubstring("RolloutDeferred")))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).NotTo(HaveKey(restartedAtAnnotation))
+
+			By("Removing the freeze from the config")
+			operatorConfig.Set(&config.FlipperConfig{DefaultInterval: &metav1.Duration{Duration: 2 * time.Hour}})
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey(restartedAtAnnotation))
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Spec.Schedule.Interval).To(BeNil())
+			Expect(rollingupdate.Status.NextRolloutTime).NotTo(BeNil())
+			Expect(rollingupdate.Status.NextRolloutTime.Time).To(BeTemporally("~", time.Now().Add(2*time.Hour), time.Minute))
+		})
+	})
+
+	Context("When the RollingUpdate names a service account", func() {
+		const resourceName = "impersonating-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "impersonating-deployment",
+			Namespace: "default",
+		}
+		roleNamespacedName := types.NamespacedName{
+			Name:      "deployment-restarter",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "impersonating"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector:           &metav1.LabelSelector{MatchLabels: map[string]string{"app": "impersonating"}},
+					ServiceAccountName: "restarter",
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+
+			role := &rbacv1.Role{}
+			if err := k8sClient.Get(ctx, roleNamespacedName, role); err == nil {
+				Expect(k8sClient.Delete(ctx, role)).To(Succeed())
+			}
+			binding := &rbacv1.RoleBinding{}
+			if err := k8sClient.Get(ctx, roleNamespacedName, binding); err == nil {
+				Expect(k8sClient.Delete(ctx, binding)).To(Succeed())
+			}
+		})
+
+		It("should restart the deployments only with the permissions of the service account", func() {
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+				Config:   cfg,
+			}
+
+			By("reconciling without permissions granted to the service account")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(errors.IsForbidden(err)).To(BeTrue())
+			Expect(recorder.Events).To(Receive(ContainSubstring("Forbidden")))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).NotTo(HaveKey(restartedAtAnnotation))
+
+			By("granting the service account access to the deployments")
+			Expect(k8sClient.Create(ctx, &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{Name: roleNamespacedName.Name, Namespace: roleNamespacedName.Namespace},
+				Rules: []rbacv1.PolicyRule{{
+					APIGroups: []string{"apps"},
+					Resources: []string{"deployments"},
+					Verbs:     []string{"get", "list", "patch"},
+				}, {
+					APIGroups: []string{""},
+					Resources: []string{"pods"},
+					Verbs:     []string{"list"},
+				}, {
+					APIGroups: []string{"policy"},
+					Resources: []string{"poddisruptionbudgets"},
+					Verbs:     []string{"list"},
+				}},
+			})).To(Succeed())
+			Expect(k8sClient.Create(ctx, &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: roleNamespacedName.Name, Namespace: roleNamespacedName.Namespace},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: roleNamespacedName.Name},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "restarter", Namespace: "default"}},
+			})).To(Succeed())
+
+			Eventually(func() error {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				return err
+			}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey(restartedAtAnnotation))
+		})
+
+		It("should not read the pods without the permissions of the service account", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+				Config:   cfg,
+			}
+
+			By("granting the service account access to the deployments and budgets only")
+			Expect(k8sClient.Create(ctx, &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{Name: roleNamespacedName.Name, Namespace: roleNamespacedName.Namespace},
+				Rules: []rbacv1.PolicyRule{{
+					APIGroups: []string{"apps"},
+					Resources: []string{"deployments"},
+					Verbs:     []string{"get", "list", "patch"},
+				}, {
+					APIGroups: []string{"policy"},
+					Resources: []string{"poddisruptionbudgets"},
+					Verbs:     []string{"list"},
+				}},
+			})).To(Succeed())
+			Expect(k8sClient.Create(ctx, &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: roleNamespacedName.Name, Namespace: roleNamespacedName.Namespace},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: roleNamespacedName.Name},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "restarter", Namespace: "default"}},
+			})).To(Succeed())
+
+			By("restarting the deployment")
+			Eventually(func() error {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				return err
+			}, 10*time.Second, 500*time.Millisecond).Should(Succeed())
+
+			By("checking the rollout without permission to list the pods")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).To(MatchError(ContainSubstring("cannot list resource \"pods\"")))
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Workloads).To(HaveLen(1))
+			Expect(rollingupdate.Status.Workloads[0].Phase).To(Equal(flipperv1beta1.WorkloadPhaseRestarting))
+		})
+	})
+
+	Context("When the RollingUpdate violates the policy", func() {
+		const resourceName = "policy-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "policy-deployment",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "policy"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "policy"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Minute}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should not start rollouts and report the violations", func() {
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+				OperatorConfig: config.NewStore(&config.FlipperConfig{
+					Policy: &policy.Policy{
+						Default: policy.Rules{MinInterval: &metav1.Duration{Duration: time.Hour}},
+					},
+				}),
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(policyRequeueInterval))
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Workloads).To(BeEmpty())
+			Expect(rollingupdate.Status.LastRolloutTime.IsZero()).To(BeTrue())
+
+			violated := meta.FindStatusCondition(rollingupdate.Status.Conditions, flipperv1beta1.ConditionPolicyViolated)
+			Expect(violated).NotTo(BeNil())
+			Expect(violated.Status).To(Equal(metav1.ConditionTrue))
+			Expect(violated.Message).To(ContainSubstring("interval 1m0s is shorter than the minimum interval 1h0m0s"))
+
+			ready := meta.FindStatusCondition(rollingupdate.Status.Conditions, flipperv1beta1.ConditionReady)
+			Expect(ready).NotTo(BeNil())
+			Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+			Expect(ready.Reason).To(Equal(flipperv1beta1.ConditionPolicyViolated))
+			Expect(recorder.Events).To(Receive(ContainSubstring("PolicyViolated")))
+		})
+	})
+
+	Context("When a rollout is requested within the minimum interval of the policy", func() {
+		const resourceName = "min-interval-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "min-interval-deployment",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "min-interval"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        resourceName,
+					Namespace:   "default",
+					Annotations: map[string]string{flipperv1beta1.TriggerAnnotation: "1"},
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "min-interval"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: 24 * time.Hour}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			// Record a rollout ten minutes ago.
+			lastRolloutTime := metav1.NewTime(time.Now().Add(-10 * time.Minute).Truncate(time.Second))
+			resource.Status.LastRolloutTime = lastRolloutTime
+			resource.Status.CycleID = lastRolloutTime.UTC().Format(time.RFC3339)
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should defer the rollout until the minimum interval passed", func() {
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+				OperatorConfig: config.NewStore(&config.FlipperConfig{
+					Policy: &policy.Policy{
+						Default: policy.Rules{MinInterval: &metav1.Duration{Duration: time.Hour}},
+					},
+				}),
+			}
+
+			By("deferring the manually requested rollout")
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically("~", 50*time.Minute, time.Minute))
+			Expect(recorder.Events).To(Receive(ContainSubstring("by the minimum interval 1h0m0s of the policy")))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).NotTo(HaveKey(restartedAtAnnotation))
+
+			By("starting the rollout once the minimum interval passed")
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.LastTrigger).To(BeEmpty())
+			lastRolloutTime := metav1.NewTime(time.Now().Add(-2 * time.Hour).Truncate(time.Second))
+			rollingupdate.Status.LastRolloutTime = lastRolloutTime
+			rollingupdate.Status.CycleID = lastRolloutTime.UTC().Format(time.RFC3339)
+			Expect(k8sClient.Status().Update(ctx, rollingupdate)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey(restartedAtAnnotation))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.LastTrigger).To(Equal("1"))
+		})
+	})
+
+	Context("When the namespace of the RollingUpdate is not watched", func() {
+		const resourceName = "unwatched-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "unwatched-deployment",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "unwatched"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "unwatched"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should refuse the RollingUpdate without restarting its deployments", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+				OperatorConfig: config.NewStore(&config.FlipperConfig{
+					WatchNamespaces: []string{"team-a", "team-b"},
+				}),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Finalizers).To(BeEmpty())
+			Expect(rollingupdate.Status.Workloads).To(BeEmpty())
+
+			ready := meta.FindStatusCondition(rollingupdate.Status.Conditions, flipperv1beta1.ConditionReady)
+			Expect(ready).NotTo(BeNil())
+			Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+			Expect(ready.Reason).To(Equal("NamespaceNotWatched"))
+			Expect(ready.Message).To(ContainSubstring("team-a, team-b"))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).NotTo(HaveKey(restartedAtAnnotation))
+		})
+	})
+
+	Context("When reporting the state of the RollingUpdate", func() {
+		const resourceName = "state-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "state-deployment",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "state"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "state"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Hour}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should record the target count and the Ready condition", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.TargetCount).To(Equal(int32(1)))
+
+			// envtest runs no deployment controller, so the rollout does not complete.
+			ready := meta.FindStatusCondition(rollingupdate.Status.Conditions, flipperv1beta1.ConditionReady)
+			Expect(ready).NotTo(BeNil())
+			Expect(ready.Status).To(Equal(metav1.ConditionUnknown))
+			Expect(ready.Reason).To(Equal("RolloutInProgress"))
+		})
+	})
+
+	Context("When rollouts are requested and suspended manually", func() {
+		const resourceName = "manual-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "manual"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Hour}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should start a rollout on request unless suspended and record the rollouts", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+			reconcileOnce := func() *flipperv1beta1.RollingUpdate {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				rollingupdate := &flipperv1beta1.RollingUpdate{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+				return rollingupdate
+			}
+
+			rollingupdate := reconcileOnce()
+			Expect(rollingupdate.Status.History).To(HaveLen(1))
+			Expect(rollingupdate.Status.History[0].Reason).To(Equal(rolloutReasonScheduled))
+			Expect(rollingupdate.Status.History[0].Result).To(Equal(flipperv1beta1.RolloutResultSucceeded))
+			Expect(rollingupdate.Status.NextRolloutTime).NotTo(BeNil())
+
+			By("Requesting a rollout while the RollingUpdate is suspended")
+			rollingupdate.Spec.Suspend = true
+			rollingupdate.Annotations = map[string]string{flipperv1beta1.TriggerAnnotation: "2024-06-18T12:00:00Z"}
+			Expect(k8sClient.Update(ctx, rollingupdate)).To(Succeed())
+
+			rollingupdate = reconcileOnce()
+			Expect(rollingupdate.Status.History).To(HaveLen(1))
+			Expect(rollingupdate.Status.NextRolloutTime).To(BeNil())
+
+			By("Resuming the RollingUpdate")
+			rollingupdate.Spec.Suspend = false
+			Expect(k8sClient.Update(ctx, rollingupdate)).To(Succeed())
+
+			rollingupdate = reconcileOnce()
+			Expect(rollingupdate.Status.History).To(HaveLen(2))
+			Expect(rollingupdate.Status.History[1].Reason).To(Equal(rolloutReasonManual))
+			Expect(rollingupdate.Status.LastTrigger).To(Equal("2024-06-18T12:00:00Z"))
+
+			By("Not repeating a handled request")
+			rollingupdate = reconcileOnce()
+			Expect(rollingupdate.Status.History).To(HaveLen(2))
+		})
+	})
+
+	Context("When anchoring the schedule", func() {
+		It("should schedule rollouts at the anchor time plus whole intervals", func() {
+			controllerReconciler := &RollingUpdateReconciler{}
+			anchorTime := metav1.NewTime(time.Date(2024, 6, 18, 12, 0, 0, 0, time.UTC))
+			now := time.Date(2024, 6, 21, 9, 20, 0, 0, time.UTC)
+			rollingUpdate := &flipperv1beta1.RollingUpdate{
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Schedule: flipperv1beta1.ScheduleSpec{
+						Interval:   &metav1.Duration{Duration: time.Hour},
+						AnchorTime: &anchorTime,
+					},
+				},
+			}
+
+			By("Scheduling the first rollout at the latest anchored time")
+			Expect(scheduledTime(rollingUpdate, time.Hour, now)).To(Equal(time.Date(2024, 6, 21, 9, 0, 0, 0, time.UTC)))
+
+			By("Scheduling the next rollout at the first anchored time after the last rollout")
+			rollingUpdate.Status.LastRolloutTime = metav1.NewTime(time.Date(2024, 6, 21, 9, 0, 7, 0, time.UTC))
+			Expect(scheduledTime(rollingUpdate, time.Hour, now)).To(Equal(time.Date(2024, 6, 21, 10, 0, 0, 0, time.UTC)))
+			Expect(controllerReconciler.nextRolloutTime(rollingUpdate, now).Time).To(Equal(time.Date(2024, 6, 21, 10, 0, 0, 0, time.UTC)))
+
+			By("Not scheduling rollouts before the anchor time")
+			future := metav1.NewTime(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+			rollingUpdate.Spec.Schedule.AnchorTime = &future
+			Expect(scheduledTime(rollingUpdate, time.Hour, now)).To(Equal(future.Time))
+
+			By("Scheduling one interval after the last rollout without an anchor time")
+			rollingUpdate.Spec.Schedule.AnchorTime = nil
+			Expect(scheduledTime(rollingUpdate, time.Hour, now)).To(Equal(time.Date(2024, 6, 21, 10, 0, 7, 0, time.UTC)))
+		})
+	})
+
+	Context("When postponing a missed rollout to the next scheduled time", func() {
+		It("should keep the original schedule", func() {
+			scheduled := time.Date(2024, 6, 18, 12, 0, 0, 0, time.UTC)
+			now := time.Date(2024, 6, 21, 9, 20, 0, 0, time.UTC)
+			Expect(nextScheduledTime(scheduled, time.Hour, now)).To(Equal(time.Date(2024, 6, 21, 10, 0, 0, 0, time.UTC)))
+			Expect(nextScheduledTime(scheduled, 6*time.Hour, now)).To(Equal(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)))
+		})
+
+		It("should not divide by an interval that is not positive", func() {
+			scheduled := time.Date(2024, 6, 18, 12, 0, 0, 0, time.UTC)
+			now := time.Date(2024, 6, 21, 9, 20, 0, 0, time.UTC)
+			Expect(nextScheduledTime(scheduled, 0, now)).To(Equal(now))
+		})
+	})
+
+	Context("When restarting a deployment fails", func() {
+		const resourceName = "restart-error-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		failingNamespacedName := types.NamespacedName{
+			Name:      "restart-error-a",
+			Namespace: "default",
+		}
+		healthyNamespacedName := types.NamespacedName{
+			Name:      "restart-error-b",
+			Namespace: "default",
+		}
+
+		newReconciler := func() *RollingUpdateReconciler {
+			watchClient, err := client.NewWithWatch(cfg, client.Options{Scheme: scheme.Scheme})
+			Expect(err).NotTo(HaveOccurred())
+
+			failingClient := interceptor.NewClient(watchClient, interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					if obj.GetName() == failingNamespacedName.Name {
+						return errors.NewInternalError(fmt.Errorf("injected failure"))
+					}
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			})
+			return &RollingUpdateReconciler{
+				Client:   failingClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+		}
+
+		BeforeEach(func() {
+			for _, name := range []types.NamespacedName{failingNamespacedName, healthyNamespacedName} {
+				deployment := newTestDeployment(name, map[string]string{"app": "restart-error"})
+				Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+			}
+
+			retries := int32(1)
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "restart-error"}},
+					Schedule:       flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Hour}},
+					RestartRetries: &retries,
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			// The finalizer is removed by a reconcile, which lets the next spec recreate the resource.
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, name := range []types.NamespacedName{failingNamespacedName, healthyNamespacedName} {
+				deployment := &appsv1.Deployment{}
+				Expect(k8sClient.Get(ctx, name, deployment)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+			}
+		})
+
+		It("should restart the other deployments and retry only the failed one", func() {
+			controllerReconciler := newReconciler()
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Workloads).To(HaveLen(2))
+			Expect(rollingupdate.Status.Workloads[0].Phase).To(Equal(flipperv1beta1.WorkloadPhasePending))
+			Expect(rollingupdate.Status.Workloads[0].Result).To(Equal(flipperv1beta1.RestartResultFailed))
+			Expect(rollingupdate.Status.Workloads[0].RestartAttempts).To(Equal(int32(1)))
+			Expect(rollingupdate.Status.Workloads[0].Message).To(ContainSubstring("injected failure"))
+			Expect(rollingupdate.Status.Workloads[1].Phase).To(Equal(flipperv1beta1.WorkloadPhaseRestarting))
+			Expect(rollingupdate.Status.Workloads[1].Result).To(Equal(flipperv1beta1.RestartResultSucceeded))
+
+			By("Giving up once the retries are exhausted")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Workloads[0].Phase).To(Equal(flipperv1beta1.WorkloadPhaseFailed))
+			Expect(rollingupdate.Status.Workloads[0].RestartAttempts).To(Equal(int32(2)))
+			Expect(rollingupdate.Status.Workloads[1].Phase).To(Equal(flipperv1beta1.WorkloadPhaseRestarting))
+		})
+
+		It("should skip the remaining deployments with the abort policy", func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.OnRestartError = flipperv1beta1.RestartErrorPolicyAbort
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			controllerReconciler := newReconciler()
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Workloads[0].Phase).To(Equal(flipperv1beta1.WorkloadPhaseFailed))
+			Expect(rollingupdate.Status.Workloads[1].Phase).To(Equal(flipperv1beta1.WorkloadPhaseAborted))
+			Expect(rollingupdate.Status.Workloads[1].Result).To(Equal(flipperv1beta1.RestartResultSkipped))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, healthyNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).NotTo(HaveKey("kubectl.kubernetes.io/restartedAt"))
+		})
+	})
+
+	Context("When the RollingUpdate changes while its status is written", func() {
+		const resourceName = "conflict-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "conflict-deployment",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "conflict"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "conflict"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Hour}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should retry the status patch without losing the concurrent change", func() {
+			watchClient, err := client.NewWithWatch(cfg, client.Options{Scheme: scheme.Scheme})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Changing the RollingUpdate right before the first two status patches")
+			conflicts := 0
+			conflictingClient := interceptor.NewClient(watchClient, interceptor.Funcs{
+				SubResourcePatch: func(ctx context.Context, c client.Client, subResource string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+					if conflicts < 2 {
+						conflicts++
+						latest := &flipperv1beta1.RollingUpdate{}
+						Expect(c.Get(ctx, client.ObjectKeyFromObject(obj), latest)).To(Succeed())
+						latest.Labels = map[string]string{"team": "payments"}
+						latest.Spec.Schedule.Interval = &metav1.Duration{Duration: 2 * time.Hour}
+						Expect(c.Update(ctx, latest)).To(Succeed())
+					}
+					return c.SubResource(subResource).Patch(ctx, obj, patch, opts...)
+				},
+			})
+
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   conflictingClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conflicts).To(Equal(2))
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Labels).To(HaveKeyWithValue("team", "payments"))
+			Expect(rollingupdate.Spec.Schedule.Interval.Duration).To(Equal(2 * time.Hour))
+			Expect(rollingupdate.Status.LastRolloutTime.IsZero()).To(BeFalse())
+			Expect(rollingupdate.Status.Workloads).To(HaveLen(1))
+		})
+	})
+
+	Context("When resuming an interrupted rollout", func() {
+		const resourceName = "resume-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "resume-deployment",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "resume"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "resume"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Hour}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should not restart a deployment already restarted by the rollout", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.CycleID).NotTo(BeEmpty())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKeyWithValue("kubectl.kubernetes.io/restartedAt", rollingupdate.Status.CycleID))
+			generation := deployment.Generation
+
+			By("Recording the deployment as pending, as if the operator stopped before updating the status")
+			rollingupdate.Status.Workloads[0].Phase = flipperv1beta1.WorkloadPhasePending
+			Expect(k8sClient.Status().Update(ctx, rollingupdate)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Workloads[0].Phase).To(Equal(flipperv1beta1.WorkloadPhaseRestarting))
+			Expect(rollingupdate.Status.Workloads[0].RestartedAt).To(Equal(rollingupdate.Status.CycleID))
+
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Generation).To(Equal(generation))
+		})
+	})
+
+	Context("When a field manager is configured", func() {
+		const resourceName = "field-manager-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "field-manager-deployment",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "field-manager"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "field-manager"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Hour}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should patch the restart annotations as that field manager", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				Recorder:       record.NewFakeRecorder(10),
+				OperatorConfig: config.NewStore(&config.FlipperConfig{FieldManager: "flipper-test"}),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey("kubectl.kubernetes.io/restartedAt"))
+
+			managers := []string{}
+			for _, entry := range deployment.ManagedFields {
+				managers = append(managers, entry.Manager)
+			}
+			Expect(managers).To(ContainElement("flipper-test"))
+		})
+	})
+
+	Context("When a RollingUpdate is deleted", func() {
+		const resourceName = "finalizer-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "finalizer-deployment",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "finalizer"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "finalizer"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Hour}},
+					Annotations: &flipperv1beta1.AnnotationSpec{
+						Placement: flipperv1beta1.AnnotationPlacementTemplateAndObject,
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should remove the restart annotations from the deployment metadata only", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			By("restarting the deployment")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Finalizers).To(ContainElement("flipper.example.com/cleanup"))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Annotations).To(HaveKeyWithValue("flipper.example.com/restartedByCR", "default/finalizer-resource"))
+
+			By("deleting the RollingUpdate")
+			Expect(k8sClient.Delete(ctx, rollingupdate)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, typeNamespacedName, rollingupdate)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Annotations).NotTo(HaveKey("flipper.example.com/restartedByCR"))
+			Expect(deployment.Annotations).NotTo(HaveKey("kubectl.kubernetes.io/restartedAt"))
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey("kubectl.kubernetes.io/restartedAt"))
+		})
+
+		It("should remove the recorded restart annotations when restartedByCR is excluded", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			rollingupdate.Spec.Annotations.Exclude = []flipperv1beta1.RestartAnnotation{flipperv1beta1.RestartAnnotationRestartedByCR}
+			Expect(k8sClient.Update(ctx, rollingupdate)).To(Succeed())
+
+			By("restarting the deployment")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.AnnotatedTargets).To(ConsistOf(flipperv1beta1.AnnotatedTarget{
+				TargetReference: flipperv1beta1.TargetReference{Kind: flipperv1beta1.TargetKindDeployment, Name: "finalizer-deployment"},
+				Annotations: []string{
+					"flipper.example.com/restartedByCRDKind",
+					"kubectl.kubernetes.io/restartedAt",
+					"kubectl.kubernetes.io/restartedBy",
+				},
+			}))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Annotations).NotTo(HaveKey("flipper.example.com/restartedByCR"))
+			Expect(deployment.Annotations).To(HaveKey("kubectl.kubernetes.io/restartedBy"))
+
+			By("deleting the RollingUpdate")
+			Expect(k8sClient.Delete(ctx, rollingupdate)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, typeNamespacedName, rollingupdate)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Annotations).NotTo(HaveKey("kubectl.kubernetes.io/restartedBy"))
+			Expect(deployment.Annotations).NotTo(HaveKey("flipper.example.com/restartedByCRDKind"))
+			Expect(deployment.Annotations).NotTo(HaveKey("kubectl.kubernetes.io/restartedAt"))
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey("kubectl.kubernetes.io/restartedAt"))
+		})
+	})
+
+	Context("When pre-flight checks fail", func() {
+		const resourceName = "preflight-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "preflight-deployment",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			By("creating a deployment that is not available")
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "preflight"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			By("creating the custom resource requiring available deployments")
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "preflight"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Minute}},
+					Preflight: &flipperv1beta1.PreflightSpec{
+						RequireDeploymentsAvailable: true,
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should defer the rollout and report the PreflightFailed condition", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			res, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.RequeueAfter).To(Equal(preflightRequeueInterval))
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.LastRolloutTime.IsZero()).To(BeTrue())
+
+			condition := meta.FindStatusCondition(rollingupdate.Status.Conditions, flipperv1beta1.ConditionPreflightFailed)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal("DeploymentUnavailable"))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).NotTo(HaveKey("kubectl.kubernetes.io/restartedAt"))
+		})
+	})
+
+	Context("When a PodDisruptionBudget allows no disruptions", func() {
+		const resourceName = "pdb-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		blockedNamespacedName := types.NamespacedName{
+			Name:      "pdb-blocked-deployment",
+			Namespace: "default",
+		}
+		freeNamespacedName := types.NamespacedName{
+			Name:      "pdb-free-deployment",
+			Namespace: "default",
+		}
+		pdbNamespacedName := types.NamespacedName{
+			Name:      "pdb-blocking",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			By("creating one deployment covered by a PodDisruptionBudget and one that is not")
+			blocked := newTestDeployment(blockedNamespacedName, map[string]string{"app": "pdb-blocked", "group": "pdb"})
+			Expect(k8sClient.Create(ctx, blocked)).To(Succeed())
+			free := newTestDeployment(freeNamespacedName, map[string]string{"app": "pdb-free", "group": "pdb"})
+			Expect(k8sClient.Create(ctx, free)).To(Succeed())
+
+			// Without a disruption controller the budget's status reports zero allowed disruptions.
+			minAvailable := intstr.FromInt32(1)
+			pdb := &policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      pdbNamespacedName.Name,
+					Namespace: pdbNamespacedName.Namespace,
+				},
+				Spec: policyv1.PodDisruptionBudgetSpec{
+					MinAvailable: &minAvailable,
+					Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "pdb-blocked"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pdb)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"group": "pdb"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Hour}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			pdb := &policyv1.PodDisruptionBudget{}
+			Expect(k8sClient.Get(ctx, pdbNamespacedName, pdb)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, pdb)).To(Succeed())
+
+			for _, name := range []types.NamespacedName{blockedNamespacedName, freeNamespacedName} {
+				deployment := &appsv1.Deployment{}
+				Expect(k8sClient.Get(ctx, name, deployment)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+			}
+		})
+
+		It("should defer only the deployment selected by the budget", func() {
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+
+			res, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			// The unblocked deployment is rolling out, which is checked more often than deferred ones.
+			Expect(res.RequeueAfter).To(Equal(rolloutRequeueInterval))
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Targets).To(ConsistOf(deploymentReference(freeNamespacedName.Name)))
+			Expect(rollingupdate.Status.Deferred).To(ConsistOf(flipperv1beta1.DeferredTarget{
+				TargetReference:     deploymentReference(blockedNamespacedName.Name),
+				PodDisruptionBudget: pdbNamespacedName.Name,
+			}))
+			Expect(recorder.Events).To(Receive(ContainSubstring("RestartDeferred")))
+
+			blocked := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, blockedNamespacedName, blocked)).To(Succeed())
+			Expect(blocked.Spec.Template.Annotations).NotTo(HaveKey("kubectl.kubernetes.io/restartedAt"))
+		})
+	})
+
+	Context("When a restarted deployment is crash-looping", func() {
+		const resourceName = "rollback-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "rollback-deployment",
+			Namespace: "default",
+		}
+		podNamespacedName := types.NamespacedName{
+			Name:      "rollback-deployment-pod",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "rollback"})
+			deployment.Spec.Template.Annotations = map[string]string{"kubectl.kubernetes.io/restartedAt": "2024-01-01T00:00:00Z"}
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector:  &metav1.LabelSelector{MatchLabels: map[string]string{"app": "rollback"}},
+					Schedule:  flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Hour}},
+					OnFailure: flipperv1beta1.FailurePolicyRollback,
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			pod := &corev1.Pod{}
+			Expect(k8sClient.Get(ctx, podNamespacedName, pod)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should revert the restart annotations and report the failure", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			By("restarting the deployment")
+			res, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.RequeueAfter).To(Equal(rolloutRequeueInterval))
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Workloads).To(HaveLen(1))
+			Expect(rollingupdate.Status.Workloads[0].Phase).To(Equal(flipperv1beta1.WorkloadPhaseRestarting))
+
+			By("simulating a crash-looping pod created by the restart")
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        podNamespacedName.Name,
+					Namespace:   podNamespacedName.Namespace,
+					Labels:      map[string]string{"app": "rollback"},
+					Annotations: map[string]string{"kubectl.kubernetes.io/restartedAt": rollingupdate.Status.Workloads[0].RestartedAt},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "nginx", Image: "nginx"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name:  "nginx",
+				Image: "nginx",
+				State: corev1.ContainerState{
+					Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+				},
+			}}
+			Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+
+			By("checking the rollout")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Workloads[0].Phase).To(Equal(flipperv1beta1.WorkloadPhaseRolledBack))
+			Expect(meta.IsStatusConditionTrue(rollingupdate.Status.Conditions, flipperv1beta1.ConditionRolloutFailed)).To(BeTrue())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).To(Equal(map[string]string{
+				"kubectl.kubernetes.io/restartedAt": "2024-01-01T00:00:00Z",
+			}))
+		})
+	})
+
+	Context("When the first of several restarted deployments is rolled back", func() {
+		const resourceName = "halt-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		failingNamespacedName := types.NamespacedName{
+			Name:      "halt-failing",
+			Namespace: "default",
+		}
+		pendingNamespacedName := types.NamespacedName{
+			Name:      "halt-pending",
+			Namespace: "default",
+		}
+		podNamespacedName := types.NamespacedName{
+			Name:      "halt-failing-pod",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			failing := newTestDeployment(failingNamespacedName, map[string]string{"app": "halt-failing"})
+			failing.Annotations = map[string]string{"kubectl.kubernetes.io/restartedAt": "2024-01-01T00:00:00Z"}
+			failing.Spec.Template.Annotations = map[string]string{"kubectl.kubernetes.io/restartedAt": "2024-01-01T00:00:00Z"}
+			Expect(k8sClient.Create(ctx, failing)).To(Succeed())
+			Expect(k8sClient.Create(ctx, newTestDeployment(pendingNamespacedName, map[string]string{"app": "halt-pending"}))).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      "app",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"halt-failing", "halt-pending"},
+					}}},
+					Schedule:    flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Hour}},
+					OnFailure:   flipperv1beta1.FailurePolicyRollback,
+					Annotations: &flipperv1beta1.AnnotationSpec{Placement: flipperv1beta1.AnnotationPlacementTemplateAndObject},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			pod := &corev1.Pod{}
+			Expect(k8sClient.Get(ctx, podNamespacedName, pod)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+
+			for _, name := range []types.NamespacedName{failingNamespacedName, pendingNamespacedName} {
+				deployment := &appsv1.Deployment{}
+				Expect(k8sClient.Get(ctx, name, deployment)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+			}
+		})
+
+		It("should restart one deployment at a time, roll back the failed one and abort the rest", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			By("starting the rollout")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Workloads).To(HaveLen(2))
+			Expect(rollingupdate.Status.Workloads[0].Name).To(Equal(failingNamespacedName.Name))
+			Expect(rollingupdate.Status.Workloads[0].Phase).To(Equal(flipperv1beta1.WorkloadPhaseRestarting))
+			Expect(rollingupdate.Status.Workloads[1].Phase).To(Equal(flipperv1beta1.WorkloadPhasePending))
+
+			pending := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, pendingNamespacedName, pending)).To(Succeed())
+			Expect(pending.Spec.Template.Annotations).NotTo(HaveKey("kubectl.kubernetes.io/restartedAt"))
+
+			By("simulating a crash-looping pod created by the restart")
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        podNamespacedName.Name,
+					Namespace:   podNamespacedName.Namespace,
+					Labels:      map[string]string{"app": "halt-failing"},
+					Annotations: map[string]string{"kubectl.kubernetes.io/restartedAt": rollingupdate.Status.Workloads[0].RestartedAt},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "nginx", Image: "nginx"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name:  "nginx",
+				Image: "nginx",
+				State: corev1.ContainerState{
+					Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+				},
+			}}
+			Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+
+			By("checking the rollout")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Workloads[0].Phase).To(Equal(flipperv1beta1.WorkloadPhaseRolledBack))
+			Expect(rollingupdate.Status.Workloads[1].Phase).To(Equal(flipperv1beta1.WorkloadPhaseAborted))
+			Expect(rollingupdate.Status.Workloads[1].Result).To(Equal(flipperv1beta1.RestartResultSkipped))
+
+			By("checking the failed deployment was reverted")
+			failing := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, failingNamespacedName, failing)).To(Succeed())
+			Expect(failing.Spec.Template.Annotations).To(Equal(map[string]string{
+				"kubectl.kubernetes.io/restartedAt": "2024-01-01T00:00:00Z",
+			}))
+			Expect(failing.Annotations).To(HaveKeyWithValue("kubectl.kubernetes.io/restartedAt", "2024-01-01T00:00:00Z"))
+			Expect(failing.Annotations).NotTo(HaveKey(restartedByCRAnnotation))
+
+			By("checking the remaining deployment was never restarted")
+			Expect(k8sClient.Get(ctx, pendingNamespacedName, pending)).To(Succeed())
+			Expect(pending.Spec.Template.Annotations).NotTo(HaveKey("kubectl.kubernetes.io/restartedAt"))
+		})
+	})
+
+	Context("When a pre-restart hook is configured", func() {
+		const resourceName = "hook-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "hook-deployment",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "hook"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "hook"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Hour}},
+					Hooks: &flipperv1beta1.RestartHooks{
+						PreRestart: &flipperv1beta1.HookSpec{
+							Scope: flipperv1beta1.HookScopeCycle,
+							Template: batchv1.JobTemplateSpec{
+								Spec: batchv1.JobSpec{
+									Template: corev1.PodTemplateSpec{
+										Spec: corev1.PodSpec{
+											Containers: []corev1.Container{{Name: "drain", Image: "busybox"}},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should restart the deployment only after the hook Job completed", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			By("creating the hook Job")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.PreRestartHook).NotTo(BeNil())
+			Expect(rollingupdate.Status.Workloads).To(HaveLen(1))
+			Expect(rollingupdate.Status.Workloads[0].Phase).To(Equal(flipperv1beta1.WorkloadPhasePending))
+
+			job := &batchv1.Job{}
+			jobNamespacedName := types.NamespacedName{Name: rollingupdate.Status.PreRestartHook.JobName, Namespace: "default"}
+			Expect(k8sClient.Get(ctx, jobNamespacedName, job)).To(Succeed())
+			Expect(metav1.IsControlledBy(job, rollingupdate)).To(BeTrue())
+			Expect(job.Spec.TTLSecondsAfterFinished).To(HaveValue(Equal(int32(3600))))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).NotTo(HaveKey("kubectl.kubernetes.io/restartedAt"))
+
+			By("completing the hook Job")
+			now := metav1.Now()
+			job.Status.StartTime = &now
+			job.Status.CompletionTime = &now
+			job.Status.Succeeded = 1
+			job.Status.Conditions = []batchv1.JobCondition{
+				{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+			}
+			Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.PreRestartHook.Phase).To(Equal(flipperv1beta1.HookPhaseSucceeded))
+			Expect(rollingupdate.Status.Workloads[0].Phase).To(Equal(flipperv1beta1.WorkloadPhaseRestarting))
+
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey("kubectl.kubernetes.io/restartedAt"))
+		})
+	})
+
+	Context("When a trigger ConfigMap changes", func() {
+		const resourceName = "trigger-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "trigger-deployment",
+			Namespace: "default",
+		}
+		configMapNamespacedName := types.NamespacedName{
+			Name:      "trigger-config",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "trigger"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      configMapNamespacedName.Name,
+					Namespace: configMapNamespacedName.Namespace,
+				},
+				Data: map[string]string{"log-level": "debug"},
+			}
+			Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "trigger"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: 24 * time.Hour}},
+					Triggers: &flipperv1beta1.RestartTriggers{
+						ConfigMaps: []flipperv1beta1.ObjectSelector{{Name: configMapNamespacedName.Name}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			// Record a recent rollout that saw different data, so only the trigger is due.
+			resource.Status.LastRolloutTime = metav1.Now()
+			resource.Status.TriggerHashes = map[string]string{"ConfigMap/trigger-config": triggerHashPrefix + "outdated"}
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			configMap := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, configMapNamespacedName, configMap)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, configMap)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should restart the deployment before the interval elapsed", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey("kubectl.kubernetes.io/restartedAt"))
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.TriggerHashes).To(HaveKey("ConfigMap/trigger-config"))
+			Expect(rollingupdate.Status.TriggerHashes["ConfigMap/trigger-config"]).To(HavePrefix(triggerHashPrefix))
+			Expect(rollingupdate.Status.TriggerHashes["ConfigMap/trigger-config"]).NotTo(Equal(triggerHashPrefix + "outdated"))
+		})
+
+		It("should replace hashes recorded without a key without restarting the deployment", func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Status.TriggerHashes = map[string]string{"ConfigMap/trigger-config": "outdated"}
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).NotTo(HaveKey("kubectl.kubernetes.io/restartedAt"))
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.TriggerHashes["ConfigMap/trigger-config"]).To(HavePrefix(triggerHashPrefix))
+		})
+
+		It("should read the trigger data through the API reader", func() {
+			watchClient, err := client.NewWithWatch(cfg, client.Options{Scheme: scheme.Scheme})
+			Expect(err).NotTo(HaveOccurred())
+
+			// The cache only holds the metadata of ConfigMaps, reading their data through it fails.
+			metadataOnlyClient := interceptor.NewClient(watchClient, interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if _, ok := obj.(*corev1.ConfigMap); ok {
+						return errors.NewInternalError(fmt.Errorf("ConfigMaps are not cached"))
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					if _, ok := list.(*corev1.ConfigMapList); ok {
+						return errors.NewInternalError(fmt.Errorf("ConfigMaps are not cached"))
+					}
+					return c.List(ctx, list, opts...)
+				},
+			})
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:    metadataOnlyClient,
+				APIReader: k8sClient,
+				Scheme:    k8sClient.Scheme(),
+				Recorder:  record.NewFakeRecorder(10),
+			}
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey("kubectl.kubernetes.io/restartedAt"))
+
+			By("mapping the metadata of the changed ConfigMap to the RollingUpdate")
+			changed := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+				Name:      configMapNamespacedName.Name,
+				Namespace: configMapNamespacedName.Namespace,
+			}}
+			Expect(controllerReconciler.rollingUpdatesForTrigger(triggerKindConfigMap)(ctx, changed)).To(ConsistOf(
+				reconcile.Request{NamespacedName: typeNamespacedName}))
+			Expect(controllerReconciler.rollingUpdatesForTrigger(triggerKindSecret)(ctx, changed)).To(BeEmpty())
+		})
+	})
+
+	Context("When an auto discovered object of one deployment changes", func() {
+		const resourceName = "partial-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		consumerNamespacedName := types.NamespacedName{
+			Name:      "partial-consumer",
+			Namespace: "default",
+		}
+		otherNamespacedName := types.NamespacedName{
+			Name:      "partial-other",
+			Namespace: "default",
+		}
+		configMapNamespacedName := types.NamespacedName{
+			Name:      "partial-config",
+			Namespace: "default",
+		}
+		lastRolloutTime := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+		nextRolloutTime := metav1.NewTime(lastRolloutTime.Add(24 * time.Hour))
+
+		BeforeEach(func() {
+			consumer := newTestDeployment(consumerNamespacedName, map[string]string{"app": "partial"})
+			consumer.Spec.Template.Spec.Volumes = []corev1.Volume{{
+				Name: "config",
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: configMapNamespacedName.Name},
+					},
+				},
+			}}
+			Expect(k8sClient.Create(ctx, consumer)).To(Succeed())
+			Expect(k8sClient.Create(ctx, newTestDeployment(otherNamespacedName, map[string]string{"app": "partial"}))).To(Succeed())
+
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      configMapNamespacedName.Name,
+					Namespace: configMapNamespacedName.Namespace,
+				},
+				Data: map[string]string{"log-level": "debug"},
+			}
+			Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "partial"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: 24 * time.Hour}},
+					Triggers: &flipperv1beta1.RestartTriggers{AutoDiscover: true},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			// Record a recent rollout of both deployments that saw different data.
+			resource.Status.LastRolloutTime = lastRolloutTime
+			resource.Status.NextRolloutTime = &nextRolloutTime
+			resource.Status.WorkloadTriggerHashes = map[string]map[string]string{
+				consumerNamespacedName.Name: {"ConfigMap/partial-config": triggerHashPrefix + "outdated"},
+				otherNamespacedName.Name:    {},
+			}
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			configMap := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, configMapNamespacedName, configMap)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, configMap)).To(Succeed())
+
+			for _, name := range []types.NamespacedName{consumerNamespacedName, otherNamespacedName} {
+				deployment := &appsv1.Deployment{}
+				Expect(k8sClient.Get(ctx, name, deployment)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+			}
+		})
+
+		It("should restart only that deployment without moving the schedule", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			consumer := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, consumerNamespacedName, consumer)).To(Succeed())
+			Expect(consumer.Spec.Template.Annotations).To(HaveKey(restartedAtAnnotation))
+			other := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, otherNamespacedName, other)).To(Succeed())
+			Expect(other.Spec.Template.Annotations).NotTo(HaveKey(restartedAtAnnotation))
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.LastRolloutTime.Time).To(BeTemporally("==", lastRolloutTime.Time))
+			Expect(rollingupdate.Status.NextRolloutTime).NotTo(BeNil())
+			Expect(rollingupdate.Status.NextRolloutTime.Time).To(BeTemporally("==", nextRolloutTime.Time))
+			Expect(rollingupdate.Status.Targets).To(ConsistOf(
+				deploymentReference(consumerNamespacedName.Name), deploymentReference(otherNamespacedName.Name)))
+
+			Expect(rollingupdate.Status.History).NotTo(BeEmpty())
+			latest := rollingupdate.Status.History[len(rollingupdate.Status.History)-1]
+			Expect(latest.Reason).To(Equal(rolloutReasonCondition))
+			Expect(latest.Targets).To(ConsistOf(deploymentReference(consumerNamespacedName.Name)))
+			Expect(latest.StartTime.Time).To(BeTemporally("~", time.Now(), time.Minute))
+		})
+	})
+
+	Context("When restarting with the evictPods method", func() {
+		const resourceName = "evict-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "evict-deployment",
+			Namespace: "default",
+		}
+		podNamespacedName := types.NamespacedName{
+			Name:      "evict-deployment-pod",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			deployment := newTestDeployment(deploymentNamespacedName, map[string]string{"app": "evict"})
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podNamespacedName.Name,
+					Namespace: podNamespacedName.Namespace,
+					Labels:    map[string]string{"app": "evict"},
+				},
+				Spec: deployment.Spec.Template.Spec,
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "evict"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: time.Hour}},
+					Method:   flipperv1beta1.RestartMethodEvictPods,
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should evict the pods without changing the pod template", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			By("planning the evictions")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Workloads).To(HaveLen(1))
+			Expect(rollingupdate.Status.Workloads[0].PendingEvictions).To(Equal([]string{podNamespacedName.Name}))
+
+			By("evicting the oldest pod")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.Workloads[0].EvictedPod).To(Equal(podNamespacedName.Name))
+
+			pod := &corev1.Pod{}
+			err = k8sClient.Get(ctx, podNamespacedName, pod)
+			Expect(errors.IsNotFound(err) || pod.DeletionTimestamp != nil).To(BeTrue())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).NotTo(HaveKey("kubectl.kubernetes.io/restartedAt"))
+		})
+	})
+
+	Context("When configuring restart annotations", func() {
+		It("should apply the prefix and exclusions", func() {
+			controllerReconciler := &RollingUpdateReconciler{}
+			rollingUpdate := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Annotations: &flipperv1beta1.AnnotationSpec{
+						Prefix:  "example.com",
+						Exclude: []flipperv1beta1.RestartAnnotation{flipperv1beta1.RestartAnnotationRestartedBy},
+					},
+				},
+			}
+
+			Expect(controllerReconciler.restartAnnotations(rollingUpdate, "2024-06-18T12:00:00Z")).To(Equal(map[string]string{
+				"kubectl.kubernetes.io/restartedAt": "2024-06-18T12:00:00Z",
+				"example.com/restartedByCR":         "default/sample",
+				"example.com/restartedByCRDKind":    "rollingupdate",
+			}))
+			Expect(annotateObject(rollingUpdate)).To(BeFalse())
+		})
+
+		It("should fall back to the prefix of the operator config", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				OperatorConfig: config.NewStore(&config.FlipperConfig{AnnotationPrefix: "ops.example.com"}),
+			}
+			rollingUpdate := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+			}
+
+			Expect(controllerReconciler.restartAnnotations(rollingUpdate, "2024-06-18T12:00:00Z")).To(Equal(map[string]string{
+				"kubectl.kubernetes.io/restartedAt":  "2024-06-18T12:00:00Z",
+				"ops.example.com/restartedBy":        "flipper-operator",
+				"ops.example.com/restartedByCR":      "default/sample",
+				"ops.example.com/restartedByCRDKind": "rollingupdate",
+			}))
+
+			By("Preferring the prefix of the RollingUpdate")
+			rollingUpdate.Spec.Annotations = &flipperv1beta1.AnnotationSpec{Prefix: "example.com"}
+			Expect(controllerReconciler.restartAnnotationKeys(rollingUpdate)).To(ContainElement("example.com/restartedBy"))
+		})
+
+		It("should find the stale annotations of a deleted RollingUpdate", func() {
+			annotations := map[string]string{
+				"kubectl.kubernetes.io/restartedAt":      "2024-06-18T12:00:00Z",
+				"kubectl.kubernetes.io/restartedBy":      "flipper-operator",
+				"flipper.example.com/restartedByCR":      "default/sample",
+				"flipper.example.com/restartedByCRDKind": "rollingupdate",
+				"deployment.kubernetes.io/revision":      "3",
+			}
+
+			Expect(staleAnnotationKeys(annotations, "default/sample")).To(Equal([]string{
+				"flipper.example.com/restartedByCR",
+				"flipper.example.com/restartedByCRDKind",
+				"kubectl.kubernetes.io/restartedAt",
+				"kubectl.kubernetes.io/restartedBy",
+			}))
+			Expect(staleAnnotationKeys(annotations, "default/other")).To(BeEmpty())
+		})
+	})
+
+	Context("When discovering the objects consumed by a deployment", func() {
+		It("should find Secrets and ConfigMaps in volumes, envFrom and env valueFrom", func() {
+			podSpec := &corev1.PodSpec{
+				Volumes: []corev1.Volume{
+					{Name: "tls", VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{SecretName: "tls"},
+					}},
+					{Name: "config", VolumeSource: corev1.VolumeSource{
+						Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+							{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "settings"}}},
+						}},
+					}},
+				},
+				Containers: []corev1.Container{{
+					Name: "app",
+					EnvFrom: []corev1.EnvFromSource{
+						{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "env"}}},
+					},
+					Env: []corev1.EnvVar{
+						{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{
+							SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "db"}, Key: "password"},
+						}},
+						{Name: "SETTING", ValueFrom: &corev1.EnvVarSource{
+							ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "settings"}, Key: "setting"},
+						}},
+					},
+				}},
+			}
+
+			Expect(consumedObjects(podSpec)).To(Equal([]string{
+				"ConfigMap/env",
+				"ConfigMap/settings",
+				"Secret/db",
+				"Secret/tls",
+			}))
+		})
+	})
+
+	Context("When checking restart thresholds", func() {
+		now := time.Now()
+		maxRestarts := int32(3)
+
+		It("should report pods older than maxPodAge", func() {
+			pods := []corev1.Pod{{
+				ObjectMeta: metav1.ObjectMeta{Name: "old", CreationTimestamp: metav1.NewTime(now.Add(-72 * time.Hour))},
+			}}
+			Expect(podsExceedingThresholds(pods, 48*time.Hour, nil, now)).To(ContainSubstring("pod old is 72h0m0s old"))
+			Expect(podsExceedingThresholds(pods, 96*time.Hour, nil, now)).To(BeEmpty())
+		})
+
+		It("should report containers restarted more than maxContainerRestarts times", func() {
+			pods := []corev1.Pod{{
+				ObjectMeta: metav1.ObjectMeta{Name: "flaky", CreationTimestamp: metav1.NewTime(now)},
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: 4}},
+				},
+			}}
+			Expect(podsExceedingThresholds(pods, 0, &maxRestarts, now)).To(ContainSubstring("container app of pod flaky restarted 4 times"))
+			Expect(podsExceedingThresholds(pods, 48*time.Hour, nil, now)).To(BeEmpty())
+		})
+	})
+
+	Context("When the metrics trigger holds for a deployment", func() {
+		const resourceName = "metrics-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		deploymentNamespacedName := types.NamespacedName{
+			Name:      "metrics-deployment",
+			Namespace: "default",
+		}
+
+		var server *httptest.Server
+		var queries atomic.Int32
+
+		BeforeEach(func() {
+			queries.Store(0)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				queries.Add(1)
+				// The error rate of the deployment is over the threshold of the query.
+				_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1718712000,"0.95"]}]}}`))
+			}))
+
+			Expect(k8sClient.Create(ctx, newTestDeployment(deploymentNamespacedName, map[string]string{"app": "metrics"}))).To(Succeed())
+
+			resource := &flipperv1beta1.RollingUpdate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: flipperv1beta1.RollingUpdateSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "metrics"}},
+					Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: 24 * time.Hour}},
+					Metrics: &flipperv1beta1.MetricsTrigger{
+						URL:      server.URL,
+						Query:    `error_rate{namespace="$namespace",deployment="$deployment"} > 0.5`,
+						Cooldown: &metav1.Duration{Duration: 30 * time.Minute},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			// Record a recent scheduled rollout, so only the metrics trigger is due.
+			resource.Status.LastRolloutTime = metav1.Now()
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			server.Close()
+
+			resource := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+		})
+
+		It("should restart the deployment once per cooldown", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				Recorder:       record.NewFakeRecorder(10),
+				OperatorConfig: config.NewStore(&config.FlipperConfig{MetricsURLs: []string{server.URL}}),
+			}
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			deployment := &appsv1.Deployment{}
+
+			// finishRollout marks the rollout as done, so the next reconcile may start another.
+			finishRollout := func() {
+				Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+				for i := range rollingupdate.Status.Workloads {
+					rollingupdate.Status.Workloads[i].Phase = flipperv1beta1.WorkloadPhaseDone
+				}
+				Expect(k8sClient.Status().Update(ctx, rollingupdate)).To(Succeed())
+			}
+
+			By("restarting the deployment when the condition holds")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(queries.Load()).To(Equal(int32(1)))
+
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey(restartedAtAnnotation))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.MetricsTriggeredAt).To(HaveKey(deploymentNamespacedName.Name))
+			triggeredAt := rollingupdate.Status.MetricsTriggeredAt[deploymentNamespacedName.Name]
+			Expect(triggeredAt.Time).To(BeTemporally("~", time.Now(), time.Minute))
+			finishRollout()
+
+			By("not restarting the deployment again within the cooldown")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(queries.Load()).To(Equal(int32(1)))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.MetricsTriggeredAt[deploymentNamespacedName.Name].Time).To(BeTemporally("==", triggeredAt.Time))
+			Expect(rolloutInProgress(rollingupdate)).To(BeFalse())
+
+			By("restarting the deployment again once the cooldown passed")
+			rollingupdate.Status.MetricsTriggeredAt[deploymentNamespacedName.Name] = metav1.NewTime(time.Now().Add(-time.Hour))
+			Expect(k8sClient.Status().Update(ctx, rollingupdate)).To(Succeed())
+			// Clear the previous restart, so the next one is visible.
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			delete(deployment.Spec.Template.Annotations, restartedAtAnnotation)
+			Expect(k8sClient.Update(ctx, deployment)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(queries.Load()).To(Equal(int32(2)))
+
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey(restartedAtAnnotation))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			Expect(rollingupdate.Status.MetricsTriggeredAt[deploymentNamespacedName.Name].Time).To(BeTemporally("~", time.Now(), time.Minute))
+		})
+
+		It("should not query a URL the operator does not allow", func() {
+			controllerReconciler := &RollingUpdateReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				Recorder:       record.NewFakeRecorder(10),
+				OperatorConfig: config.NewStore(&config.FlipperConfig{MetricsURLs: []string{"http://prometheus.monitoring.svc:9090"}}),
+			}
+
+			res, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.RequeueAfter).To(Equal(policyRequeueInterval))
+			Expect(queries.Load()).To(BeZero())
+
+			rollingupdate := &flipperv1beta1.RollingUpdate{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, rollingupdate)).To(Succeed())
+			condition := meta.FindStatusCondition(rollingupdate.Status.Conditions, flipperv1beta1.ConditionPolicyViolated)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Message).To(ContainSubstring("is not one of the metricsURLs of the operator"))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).NotTo(HaveKey(restartedAtAnnotation))
+		})
+	})
+
+	Context("When querying Prometheus for the metrics trigger", func() {
+		ctx := context.Background()
+
+		It("should hold only when the query returns samples", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				Expect(req.URL.Path).To(Equal("/api/v1/query"))
+				switch req.URL.Query().Get("query") {
+				case "high":
+					_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1718712000,"0.95"]}]}}`))
+				case "low":
+					_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+				case "scalar":
+					_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1718712000,"1"]}}`))
+				default:
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+				}
+			}))
+			defer server.Close()
+
+			controllerReconciler := &RollingUpdateReconciler{}
+			Expect(controllerReconciler.queryPrometheus(ctx, server.URL, "high")).To(BeTrue())
+			Expect(controllerReconciler.queryPrometheus(ctx, server.URL, "low")).To(BeFalse())
+			Expect(controllerReconciler.queryPrometheus(ctx, server.URL+"/", "scalar")).To(BeTrue())
+
+			_, err := controllerReconciler.queryPrometheus(ctx, server.URL, "invalid(")
+			Expect(err).To(MatchError(ContainSubstring("parse error")))
+		})
+	})
+
+	Context("When probing a verification endpoint", func() {
+		ctx := context.Background()
+
+		It("should pass only on the expected status code", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/healthz" {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			controllerReconciler := &RollingUpdateReconciler{}
+			Expect(controllerReconciler.probeHTTP(ctx, server.URL+"/healthz", http.StatusOK, time.Second)).To(Succeed())
+			Expect(controllerReconciler.probeHTTP(ctx, server.URL+"/ready", http.StatusOK, time.Second)).
+				To(MatchError(ContainSubstring("expected status 200, got 503")))
+		})
+
+		It("should fail when the endpoint does not answer in time", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				time.Sleep(200 * time.Millisecond)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			controllerReconciler := &RollingUpdateReconciler{}
+			Expect(controllerReconciler.probeHTTP(ctx, server.URL, http.StatusOK, 50*time.Millisecond)).NotTo(Succeed())
+		})
+	})
+})
+
+func newTestDeployment(name types.NamespacedName, labels map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "nginx", Image: "nginx"}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,589 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+const (
+	// rolloutRequeueInterval is how often the progress of restarted deployments is checked.
+	rolloutRequeueInterval = 15 * time.Second
+
+	// defaultRestartRetries is used when the number of restart retries is not set.
+	defaultRestartRetries = 3
+)
+
+// rolloutInProgress reports whether rollingUpdate has hooks that are still running, restarted
+// deployments that are still rolling out or being verified, or failed deployments that pause
+// further rollouts until they recover.
+func rolloutInProgress(rollingUpdate *flipperv1beta1.RollingUpdate) bool {
+	for _, hook := range []*flipperv1beta1.HookStatus{rollingUpdate.Status.PreRestartHook, rollingUpdate.Status.PostRestartHook} {
+		if hook != nil && hook.Phase == flipperv1beta1.HookPhaseRunning {
+			return true
+		}
+	}
+	for _, workload := range rollingUpdate.Status.Workloads {
+		switch workload.Phase {
+		case flipperv1beta1.WorkloadPhasePending,
+			flipperv1beta1.WorkloadPhaseRestarting,
+			flipperv1beta1.WorkloadPhaseVerifying,
+			flipperv1beta1.WorkloadPhaseFinalizing:
+			return true
+		case flipperv1beta1.WorkloadPhaseFailed:
+			// Deployments that could not be restarted have no rollout to recover from.
+			if rollingUpdate.Spec.OnFailure == flipperv1beta1.FailurePolicyPause && workload.Result != flipperv1beta1.RestartResultFailed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkRollouts advances every deployment of the current rollout of rollingUpdate through its
+// hooks, restart, rollout and verification, applies the failure policy to the deployments whose
+// rollout failed, and aborts the rollout when a hook fails. With the rollback policy, the
+// deployments are restarted one at a time, each once the previous one settled, so a rollback
+// halts the deployments not restarted yet.
+func (r *RollingUpdateReconciler) checkRollouts(ctx context.Context, req ctrl.Request, rollingUpdate *flipperv1beta1.RollingUpdate) error {
+	log := r.Log.WithValues("namespace", req.Namespace, "name", req.Name)
+
+	abortMessage := ""
+	if hook := rollingUpdate.Status.PreRestartHook; hook != nil && hook.Phase == flipperv1beta1.HookPhaseRunning {
+		phase, message, err := r.hookJobResult(ctx, req.Namespace, hook.JobName)
+		if err != nil {
+			return err
+		}
+		hook.Phase = phase
+		hook.Message = message
+		if phase == flipperv1beta1.HookPhaseFailed {
+			abortMessage = fmt.Sprintf("pre-restart %s", message)
+		}
+	}
+	cycleHookDone := rollingUpdate.Status.PreRestartHook == nil || rollingUpdate.Status.PreRestartHook.Phase == flipperv1beta1.HookPhaseSucceeded
+	// With the abort policy, no further deployment is restarted while a failed restart is retried.
+	restartBlocked := false
+	sequential := rollingUpdate.Spec.OnFailure == flipperv1beta1.FailurePolicyRollback
+	// With the rollback policy, an earlier deployment still in progress holds the pending ones.
+	earlierInProgress := false
+
+	c, err := r.targetClient(rollingUpdate)
+	if err != nil {
+		return err
+	}
+	workloads := []flipperv1beta1.WorkloadStatus{}
+	for _, workload := range rollingUpdate.Status.Workloads {
+		if workload.Phase == flipperv1beta1.WorkloadPhaseDone ||
+			workload.Phase == flipperv1beta1.WorkloadPhaseRolledBack ||
+			workload.Phase == flipperv1beta1.WorkloadPhaseAborted {
+			workloads = append(workloads, workload)
+			continue
+		}
+
+		deployment := &appsv1.Deployment{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: workload.Name}, deployment)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				log.V(1).Info("Restarted deployment no longer exists", "deployment", workload.Name)
+				continue
+			}
+			return fmt.Errorf("failed to get Deployment %s/%s: %v", req.Namespace, workload.Name, err)
+		}
+
+		switch workload.Phase {
+		case flipperv1beta1.WorkloadPhasePending:
+			if !cycleHookDone || restartBlocked || (sequential && earlierInProgress) {
+				break
+			}
+			if hook := hookOf(rollingUpdate, hookPreRestart, flipperv1beta1.HookScopeWorkload); hook != nil {
+				if workload.HookJob == "" {
+					job, err := r.createHookJob(ctx, rollingUpdate, hook, hookPreRestart, workload.Name)
+					if err != nil {
+						return err
+					}
+					workload.HookJob = job
+					break
+				}
+				phase, message, err := r.hookJobResult(ctx, req.Namespace, workload.HookJob)
+				if err != nil {
+					return err
+				}
+				if phase == flipperv1beta1.HookPhaseFailed {
+					workload.Phase = flipperv1beta1.WorkloadPhaseFailed
+					workload.Message = fmt.Sprintf("pre-restart %s", message)
+					abortMessage = workload.Message
+					break
+				}
+				if phase == flipperv1beta1.HookPhaseRunning {
+					break
+				}
+				workload.HookJob = ""
+			}
+			if err := r.restartWorkload(ctx, rollingUpdate, &workload, deployment); err != nil {
+				if message := r.restartFailed(rollingUpdate, &workload, err); message != "" {
+					abortMessage = message
+				}
+				restartBlocked = rollingUpdate.Spec.OnRestartError == flipperv1beta1.RestartErrorPolicyAbort
+				break
+			}
+			workload.Result = flipperv1beta1.RestartResultSucceeded
+			workload.Message = ""
+
+		case flipperv1beta1.WorkloadPhaseRestarting:
+			message, err := r.rolloutFailure(ctx, rollingUpdate, deployment, workload.RestartedAt)
+			if err != nil {
+				return err
+			}
+			if message != "" {
+				halt, err := r.failWorkload(ctx, rollingUpdate, &workload, deployment, message)
+				if err != nil {
+					return err
+				}
+				if halt != "" {
+					abortMessage = halt
+					restartBlocked = true
+				}
+				break
+			}
+			if rollingUpdate.Spec.Method == flipperv1beta1.RestartMethodEvictPods {
+				done, err := r.continueEvictions(ctx, rollingUpdate, &workload, deployment)
+				if err != nil {
+					return err
+				}
+				if !done {
+					break
+				}
+			} else if !isRolloutComplete(deployment) {
+				break
+			}
+			log.V(1).Info("Deployment rollout completed", "deployment", workload.Name)
+			workload.Phase = flipperv1beta1.WorkloadPhaseVerifying
+			fallthrough
+
+		case flipperv1beta1.WorkloadPhaseVerifying:
+			message, retries := r.verifyWorkload(ctx, rollingUpdate, workload.Name)
+			if message != "" {
+				workload.VerificationAttempts++
+				workload.Message = message
+				if workload.VerificationAttempts > retries {
+					halt, err := r.failWorkload(ctx, rollingUpdate, &workload, deployment, message)
+					if err != nil {
+						return err
+					}
+					if halt != "" {
+						abortMessage = halt
+						restartBlocked = true
+					}
+				}
+				break
+			}
+			log.V(1).Info("Deployment verified", "deployment", workload.Name)
+			workload.Message = ""
+			hook := hookOf(rollingUpdate, hookPostRestart, flipperv1beta1.HookScopeWorkload)
+			if hook == nil {
+				workload.Phase = flipperv1beta1.WorkloadPhaseDone
+				break
+			}
+			job, err := r.createHookJob(ctx, rollingUpdate, hook, hookPostRestart, workload.Name)
+			if err != nil {
+				return err
+			}
+			workload.Phase = flipperv1beta1.WorkloadPhaseFinalizing
+			workload.HookJob = job
+
+		case flipperv1beta1.WorkloadPhaseFinalizing:
+			phase, message, err := r.hookJobResult(ctx, req.Namespace, workload.HookJob)
+			if err != nil {
+				return err
+			}
+			if phase == flipperv1beta1.HookPhaseRunning {
+				break
+			}
+			workload.HookJob = ""
+			if phase == flipperv1beta1.HookPhaseFailed {
+				workload.Phase = flipperv1beta1.WorkloadPhaseFailed
+				workload.Message = fmt.Sprintf("post-restart %s", message)
+				abortMessage = workload.Message
+				break
+			}
+			workload.Phase = flipperv1beta1.WorkloadPhaseDone
+
+		case flipperv1beta1.WorkloadPhaseFailed:
+			// A failed deployment recovers once its rollout completes and its checks pass.
+			if workload.Result == flipperv1beta1.RestartResultFailed || !isRolloutComplete(deployment) {
+				break
+			}
+			if message, _ := r.verifyWorkload(ctx, rollingUpdate, workload.Name); message != "" {
+				break
+			}
+			r.Recorder.Eventf(rollingUpdate, corev1.EventTypeNormal, "RolloutRecovered",
+				"Deployment %s recovered from the failed rollout", workload.Name)
+			workload.Phase = flipperv1beta1.WorkloadPhaseDone
+			workload.Message = ""
+		}
+		switch workload.Phase {
+		case flipperv1beta1.WorkloadPhasePending,
+			flipperv1beta1.WorkloadPhaseRestarting,
+			flipperv1beta1.WorkloadPhaseVerifying,
+			flipperv1beta1.WorkloadPhaseFinalizing:
+			earlierInProgress = true
+		}
+		workloads = append(workloads, workload)
+	}
+	rollingUpdate.Status.Workloads = workloads
+
+	if abortMessage != "" {
+		r.abortRollout(rollingUpdate, abortMessage)
+	} else if err := r.checkCyclePostRestartHook(ctx, rollingUpdate); err != nil {
+		return err
+	}
+
+	setRolloutFailedCondition(rollingUpdate)
+	return nil
+}
+
+// abortRollout stops the current rollout of rollingUpdate after a hook or a restart failed.
+// Deployments that were not restarted yet are marked as aborted and skipped and deferred
+// deployments are dropped; deployments already restarted keep rolling out.
+func (r *RollingUpdateReconciler) abortRollout(rollingUpdate *flipperv1beta1.RollingUpdate, message string) {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+
+	log.Info("Aborting rollout", "reason", message)
+	r.Recorder.Eventf(rollingUpdate, corev1.EventTypeWarning, "RolloutAborted", "Rollout aborted: %s", message)
+	for i := range rollingUpdate.Status.Workloads {
+		workload := &rollingUpdate.Status.Workloads[i]
+		if workload.Phase == flipperv1beta1.WorkloadPhasePending {
+			workload.Phase = flipperv1beta1.WorkloadPhaseAborted
+			workload.Result = flipperv1beta1.RestartResultSkipped
+			workload.HookJob = ""
+			workload.Message = message
+		}
+	}
+	rollingUpdate.Status.Deferred = nil
+}
+
+// checkCyclePostRestartHook creates the cycle scoped post-restart hook Job of rollingUpdate once
+// all deployments of the rollout are settled, and tracks it until it finishes.
+func (r *RollingUpdateReconciler) checkCyclePostRestartHook(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) error {
+	hook := hookOf(rollingUpdate, hookPostRestart, flipperv1beta1.HookScopeCycle)
+	if hook == nil {
+		return nil
+	}
+	if pre := rollingUpdate.Status.PreRestartHook; pre != nil && pre.Phase == flipperv1beta1.HookPhaseFailed {
+		return nil
+	}
+
+	status := rollingUpdate.Status.PostRestartHook
+	if status == nil {
+		for _, workload := range rollingUpdate.Status.Workloads {
+			switch workload.Phase {
+			case flipperv1beta1.WorkloadPhasePending,
+				flipperv1beta1.WorkloadPhaseRestarting,
+				flipperv1beta1.WorkloadPhaseVerifying,
+				flipperv1beta1.WorkloadPhaseFinalizing:
+				return nil
+			}
+		}
+		job, err := r.createHookJob(ctx, rollingUpdate, hook, hookPostRestart, "")
+		if err != nil {
+			return err
+		}
+		rollingUpdate.Status.PostRestartHook = &flipperv1beta1.HookStatus{
+			JobName: job,
+			Phase:   flipperv1beta1.HookPhaseRunning,
+		}
+		return nil
+	}
+
+	if status.Phase != flipperv1beta1.HookPhaseRunning {
+		return nil
+	}
+	phase, message, err := r.hookJobResult(ctx, rollingUpdate.Namespace, status.JobName)
+	if err != nil {
+		return err
+	}
+	status.Phase = phase
+	status.Message = message
+	if phase == flipperv1beta1.HookPhaseFailed {
+		r.Recorder.Eventf(rollingUpdate, corev1.EventTypeWarning, "HookFailed", "Post-restart %s", message)
+	}
+	return nil
+}
+
+// restartFailed records the failed attempt to restart workload. The workload stays pending, so
+// the restart is retried on the next reconcile, until all retries of rollingUpdate failed and the
+// workload is marked as failed. It returns the message to abort the rollout with when the retries
+// are exhausted and the abort policy is selected, or an empty string.
+func (r *RollingUpdateReconciler) restartFailed(rollingUpdate *flipperv1beta1.RollingUpdate, workload *flipperv1beta1.WorkloadStatus, err error) string {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+
+	retries := int32(defaultRestartRetries)
+	if rollingUpdate.Spec.RestartRetries != nil {
+		retries = *rollingUpdate.Spec.RestartRetries
+	}
+
+	workload.Result = flipperv1beta1.RestartResultFailed
+	workload.RestartAttempts++
+	workload.Message = err.Error()
+	if workload.RestartAttempts <= retries {
+		log.Info("Failed to restart deployment, retrying", "deployment", workload.Name, "attempts", workload.RestartAttempts, "error", err.Error())
+		return ""
+	}
+
+	log.Info("Failed to restart deployment, giving up", "deployment", workload.Name, "attempts", workload.RestartAttempts, "onRestartError", rollingUpdate.Spec.OnRestartError)
+	r.Recorder.Eventf(rollingUpdate, corev1.EventTypeWarning, "RestartFailed",
+		"Failed to restart deployment %s after %d attempts: %v", workload.Name, workload.RestartAttempts, err)
+	workload.Phase = flipperv1beta1.WorkloadPhaseFailed
+	if rollingUpdate.Spec.OnRestartError == flipperv1beta1.RestartErrorPolicyAbort {
+		return fmt.Sprintf("restart of deployment %s failed: %v", workload.Name, err)
+	}
+	return ""
+}
+
+// failWorkload marks workload as failed with message and applies the OnFailure policy of
+// rollingUpdate to deployment. With the rollback policy, it returns the message to halt the
+// remaining deployments of the rollout with, or an empty string otherwise.
+func (r *RollingUpdateReconciler) failWorkload(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, workload *flipperv1beta1.WorkloadStatus, deployment *appsv1.Deployment, message string) (string, error) {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+
+	log.Info("Deployment rollout failed", "deployment", workload.Name, "reason", message, "onFailure", rollingUpdate.Spec.OnFailure)
+	r.Recorder.Eventf(rollingUpdate, corev1.EventTypeWarning, "RolloutFailed", "Rollout of deployment %s failed: %s", workload.Name, message)
+	workload.Phase = flipperv1beta1.WorkloadPhaseFailed
+	workload.Message = message
+
+	if rollingUpdate.Spec.OnFailure != flipperv1beta1.FailurePolicyRollback {
+		return "", nil
+	}
+	if rollingUpdate.Spec.Method == flipperv1beta1.RestartMethodEvictPods {
+		// Evicted pods cannot be brought back, so only the remaining evictions are stopped.
+		workload.PendingEvictions = nil
+		r.Recorder.Eventf(rollingUpdate, corev1.EventTypeNormal, "RolledBack",
+			"Stopped evicting pods of deployment %s, halting the remaining deployments", workload.Name)
+	} else {
+		if err := r.rollbackDeployment(ctx, rollingUpdate, deployment, workload.PreviousAnnotations); err != nil {
+			return "", err
+		}
+		r.Recorder.Eventf(rollingUpdate, corev1.EventTypeNormal, "RolledBack",
+			"Reverted restart annotations of deployment %s, halting the remaining deployments", workload.Name)
+	}
+	workload.Phase = flipperv1beta1.WorkloadPhaseRolledBack
+	return fmt.Sprintf("deployment %s was rolled back: %s", workload.Name, message), nil
+}
+
+// rolloutFailure returns a description of why the rollout of deployment triggered by the restart
+// at restartedAt failed, or an empty string if the rollout has not failed.
+func (r *RollingUpdateReconciler) rolloutFailure(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, deployment *appsv1.Deployment, restartedAt string) (string, error) {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing &&
+			condition.Status == corev1.ConditionFalse &&
+			condition.Reason == "ProgressDeadlineExceeded" {
+			return "rollout exceeded its progress deadline", nil
+		}
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return "", fmt.Errorf("invalid selector in Deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
+	}
+	c, err := r.targetClient(rollingUpdate)
+	if err != nil {
+		return "", err
+	}
+	pods := &corev1.PodList{}
+	err = c.List(ctx, pods, client.InNamespace(deployment.Namespace), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods of Deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
+	}
+	for _, pod := range pods.Items {
+		if rollingUpdate.Spec.Method == flipperv1beta1.RestartMethodEvictPods {
+			// Evictions leave the pod template unchanged, so replacements are recognized by
+			// their creation time.
+			restartTime, err := time.Parse(time.RFC3339, restartedAt)
+			if err != nil || pod.CreationTimestamp.Time.Before(restartTime) {
+				continue
+			}
+		} else if pod.Annotations[restartedAtAnnotation] != restartedAt {
+			// Only pods created by this restart carry its restartedAt annotation value.
+			continue
+		}
+		for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+			for _, status := range statuses {
+				if status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+					return fmt.Sprintf("container %s of pod %s is crash-looping", status.Name, pod.Name), nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// rollbackDeployment restores the restart annotations of rollingUpdate in the pod template of
+// deployment to the values they had before the restart, which rolls the deployment back to its
+// previous pod template. The annotations written to the metadata of the deployment are restored
+// to the same values.
+func (r *RollingUpdateReconciler) rollbackDeployment(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, deployment *appsv1.Deployment, previous map[string]string) error {
+	log := r.Log.WithValues("namespace", deployment.Namespace, "name", deployment.Name)
+
+	c, err := r.targetClient(rollingUpdate)
+	if err != nil {
+		return err
+	}
+	original := deployment.DeepCopy()
+	revert := func(annotations map[string]string) map[string]string {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		for _, key := range r.restartAnnotationKeys(rollingUpdate) {
+			if value, ok := previous[key]; ok {
+				annotations[key] = value
+			} else {
+				delete(annotations, key)
+			}
+		}
+		return annotations
+	}
+	deployment.Spec.Template.Annotations = revert(deployment.Spec.Template.Annotations)
+	if annotateObject(rollingUpdate) {
+		deployment.Annotations = revert(deployment.Annotations)
+	}
+
+	err = c.Patch(ctx, deployment, client.MergeFrom(original), client.FieldOwner(r.fieldManager()))
+	if err != nil {
+		log.Error(err, "Failed to roll back deployment")
+		return fmt.Errorf("failed to roll back Deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
+	}
+
+	log.Info("Successfully rolled back deployment")
+	return nil
+}
+
+// isRolloutComplete reports whether all replicas of deployment run the latest pod template and
+// are available, following the same rules as "kubectl rollout status".
+func isRolloutComplete(deployment *appsv1.Deployment) bool {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.UpdatedReplicas >= replicas &&
+		deployment.Status.Replicas == deployment.Status.UpdatedReplicas &&
+		deployment.Status.AvailableReplicas >= deployment.Status.UpdatedReplicas
+}
+
+func setRolloutFailedCondition(rollingUpdate *flipperv1beta1.RollingUpdate) {
+	for _, hook := range []*flipperv1beta1.HookStatus{rollingUpdate.Status.PreRestartHook, rollingUpdate.Status.PostRestartHook} {
+		if hook != nil && hook.Phase == flipperv1beta1.HookPhaseFailed {
+			meta.SetStatusCondition(&rollingUpdate.Status.Conditions, metav1.Condition{
+				Type:               flipperv1beta1.ConditionRolloutFailed,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: rollingUpdate.Generation,
+				Reason:             "HookFailed",
+				Message:            hook.Message,
+			})
+			return
+		}
+	}
+	for _, workload := range rollingUpdate.Status.Workloads {
+		if workload.Phase == flipperv1beta1.WorkloadPhaseFailed || workload.Phase == flipperv1beta1.WorkloadPhaseRolledBack {
+			meta.SetStatusCondition(&rollingUpdate.Status.Conditions, metav1.Condition{
+				Type:               flipperv1beta1.ConditionRolloutFailed,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: rollingUpdate.Generation,
+				Reason:             string(workload.Phase),
+				Message:            fmt.Sprintf("Rollout of deployment %s failed: %s", workload.Name, workload.Message),
+			})
+			return
+		}
+	}
+	meta.SetStatusCondition(&rollingUpdate.Status.Conditions, metav1.Condition{
+		Type:               flipperv1beta1.ConditionRolloutFailed,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: rollingUpdate.Generation,
+		Reason:             "RolloutSucceeded",
+		Message:            "No restarted deployment failed to roll out",
+	})
+}
+
+// setReadyCondition derives the Ready condition of rollingUpdate from its other conditions and
+// the state of its latest rollout, and reports whether the condition changed.
+func setReadyCondition(rollingUpdate *flipperv1beta1.RollingUpdate) bool {
+	ready := metav1.Condition{
+		Type:               flipperv1beta1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: rollingUpdate.Generation,
+		Reason:             "Idle",
+		Message:            "Waiting for the next rollout",
+	}
+	violated := meta.FindStatusCondition(rollingUpdate.Status.Conditions, flipperv1beta1.ConditionPolicyViolated)
+	preflight := meta.FindStatusCondition(rollingUpdate.Status.Conditions, flipperv1beta1.ConditionPreflightFailed)
+	failed := meta.FindStatusCondition(rollingUpdate.Status.Conditions, flipperv1beta1.ConditionRolloutFailed)
+	switch {
+	case violated != nil && violated.Status == metav1.ConditionTrue:
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = flipperv1beta1.ConditionPolicyViolated
+		ready.Message = violated.Message
+	case preflight != nil && preflight.Status == metav1.ConditionTrue:
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = flipperv1beta1.ConditionPreflightFailed
+		ready.Message = preflight.Message
+	case rolloutInProgress(rollingUpdate):
+		ready.Status = metav1.ConditionUnknown
+		ready.Reason = "RolloutInProgress"
+		ready.Message = fmt.Sprintf("Rollout %s is in progress", cycleID(rollingUpdate))
+	case failed != nil && failed.Status == metav1.ConditionTrue:
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = flipperv1beta1.ConditionRolloutFailed
+		ready.Message = failed.Message
+	case rollingUpdate.Spec.Suspend:
+		ready.Reason = "Suspended"
+		ready.Message = "New rollouts are suspended"
+	}
+	return meta.SetStatusCondition(&rollingUpdate.Status.Conditions, ready)
+}
+
+func workloadTargets(workloads []flipperv1beta1.WorkloadStatus) []flipperv1beta1.TargetReference {
+	targets := []flipperv1beta1.TargetReference{}
+	for _, workload := range workloads {
+		targets = append(targets, workload.TargetReference)
+	}
+	return targets
+}
+
+// deploymentTargets returns the references to deployments.
+func deploymentTargets(deployments []appsv1.Deployment) []flipperv1beta1.TargetReference {
+	targets := []flipperv1beta1.TargetReference{}
+	for _, deployment := range deployments {
+		targets = append(targets, deploymentReference(deployment.Name))
+	}
+	return targets
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// checkSchedule reports whether the rollout due by the interval of rollingUpdate is due at now,
+// and returns the time it is due at. A rollout late by more than the starting deadline, or by
+// more than one interval without a deadline, was missed: the missed schedule policy is applied
+// and the decision is recorded in the status of rollingUpdate and reported through an event.
+func (r *RollingUpdateReconciler) checkSchedule(rollingUpdate *flipperv1beta1.RollingUpdate, interval time.Duration, now time.Time) (bool, time.Time) {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+
+	scheduled := scheduledTime(rollingUpdate, interval, now)
+	if scheduled.IsZero() {
+		return true, now
+	}
+	if now.Before(scheduled) {
+		return false, scheduled
+	}
+
+	deadline := durationOrDefault(rollingUpdate.Spec.Schedule.StartingDeadline, interval)
+	late := now.Sub(scheduled)
+	if late <= deadline {
+		return true, scheduled
+	}
+	// A missed rollout started immediately may have been deferred, for example by failed
+	// pre-flight checks; the decision was already taken.
+	if missed := rollingUpdate.Status.MissedSchedule; missed != nil && missed.ScheduledTime.Time.Equal(scheduled.Truncate(time.Second)) {
+		return true, scheduled
+	}
+
+	missed := &flipperv1beta1.MissedSchedule{
+		ScheduledTime: metav1.NewTime(scheduled),
+		DetectedTime:  metav1.NewTime(now),
+	}
+	switch rollingUpdate.Spec.Schedule.MissedSchedulePolicy {
+	case flipperv1beta1.MissedSchedulePolicySkip:
+		// An anchored schedule is kept, so the next rollout is due at the next anchored time.
+		next := metav1.NewTime(now.Add(interval))
+		if rollingUpdate.Spec.Schedule.AnchorTime != nil {
+			next = metav1.NewTime(nextScheduledTime(scheduled, interval, now))
+		}
+		missed.Decision = flipperv1beta1.MissedScheduleSkipped
+		missed.NextTime = &next
+		missed.Message = fmt.Sprintf("rollout due at %s was missed by %s, skipped until %s",
+			scheduled.Format(time.RFC3339), late.Truncate(time.Second), next.Format(time.RFC3339))
+	case flipperv1beta1.MissedSchedulePolicyRunInNextWindow:
+		next := metav1.NewTime(nextScheduledTime(scheduled, interval, now))
+		missed.Decision = flipperv1beta1.MissedSchedulePostponed
+		missed.NextTime = &next
+		missed.Message = fmt.Sprintf("rollout due at %s was missed by %s, postponed to %s",
+			scheduled.Format(time.RFC3339), late.Truncate(time.Second), next.Format(time.RFC3339))
+	default:
+		missed.Decision = flipperv1beta1.MissedScheduleRanImmediately
+		missed.Message = fmt.Sprintf("rollout due at %s was missed by %s, started immediately",
+			scheduled.Format(time.RFC3339), late.Truncate(time.Second))
+	}
+
+	log.Info("Scheduled rollout was missed", "scheduledTime", scheduled, "decision", missed.Decision, "policy", rollingUpdate.Spec.Schedule.MissedSchedulePolicy)
+	r.Recorder.Eventf(rollingUpdate, corev1.EventTypeWarning, "ScheduleMissed", "The %s", missed.Message)
+	rollingUpdate.Status.MissedSchedule = missed
+
+	if missed.NextTime != nil {
+		return false, missed.NextTime.Time
+	}
+	return true, scheduled
+}
+
+// rolloutInterval returns the interval between the rollouts of rollingUpdate, which defaults to
+// the interval of the operator config.
+func (r *RollingUpdateReconciler) rolloutInterval(rollingUpdate *flipperv1beta1.RollingUpdate) time.Duration {
+	return durationOrDefault(rollingUpdate.Spec.Schedule.Interval, r.OperatorConfig.Get().DefaultInterval.Duration)
+}
+
+// scheduledTime returns when the next rollout due by the interval of rollingUpdate is scheduled
+// at now. It is zero for the first rollout without an anchor time, which is due right away.
+func scheduledTime(rollingUpdate *flipperv1beta1.RollingUpdate, interval time.Duration, now time.Time) time.Time {
+	last := rollingUpdate.Status.LastRolloutTime.Time
+
+	var scheduled time.Time
+	switch anchor := rollingUpdate.Spec.Schedule.AnchorTime; {
+	case anchor != nil && last.IsZero():
+		// The first rollout is due at the latest anchored time.
+		scheduled = anchor.Time
+		if now.After(anchor.Time) {
+			scheduled = nextScheduledTime(anchor.Time, interval, now).Add(-interval)
+		}
+	case anchor != nil:
+		scheduled = anchor.Time
+		if !last.Before(anchor.Time) {
+			scheduled = nextScheduledTime(anchor.Time, interval, last)
+		}
+	case !last.IsZero():
+		scheduled = last.Add(interval)
+	}
+
+	// A skipped or postponed rollout is due at the time recorded with the decision, until the
+	// next rollout started.
+	if missed := rollingUpdate.Status.MissedSchedule; missed != nil && missed.NextTime != nil && missed.NextTime.After(last) {
+		scheduled = missed.NextTime.Time
+	}
+	return scheduled
+}
+
+// nextRolloutTime returns the time of the next rollout due by the interval of rollingUpdate at
+// now, as reported in its status, or nil if rollouts are driven by thresholds or the first
+// rollout is due right away.
+func (r *RollingUpdateReconciler) nextRolloutTime(rollingUpdate *flipperv1beta1.RollingUpdate, now time.Time) *metav1.Time {
+	if rollingUpdate.Spec.Thresholds != nil {
+		return nil
+	}
+	scheduled := scheduledTime(rollingUpdate, r.rolloutInterval(rollingUpdate), now)
+	if scheduled.IsZero() {
+		return nil
+	}
+	// The status is stored with a precision of one second.
+	next := metav1.NewTime(scheduled.Truncate(time.Second))
+	return &next
+}
+
+// nextScheduledTime returns the first time after now of the schedule firing every interval from
+// scheduled, which must not be after now. A schedule without a positive interval fires at now.
+func nextScheduledTime(scheduled time.Time, interval time.Duration, now time.Time) time.Time {
+	if interval <= 0 {
+		return now
+	}
+	return scheduled.Add((now.Sub(scheduled)/interval + 1) * interval)
+}
@@ -32,7 +32,7 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
-	flipperv1alpha1 "github.com/sigsegv1989/flipper-operator/api/v1alpha1"
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -72,7 +72,7 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	Expect(cfg).NotTo(BeNil())
 
-	err = flipperv1alpha1.AddToScheme(scheme.Scheme)
+	err = flipperv1beta1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
 	// +kubebuilder:scaffold:scheme
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// thresholdCheckInterval is how often the pods of the targets are inspected when restarts are
+// driven by thresholds.
+const thresholdCheckInterval = 5 * time.Minute
+
+const (
+	reasonThresholdExceeded   = "ThresholdExceeded"
+	reasonMetricsConditionMet = "MetricsConditionMet"
+)
+
+// restartCondition describes why a single deployment is due for a restart. Reason is used as
+// the reason of the event emitted when the deployment is restarted.
+type restartCondition struct {
+	Deployment string
+	Reason     string
+	Message    string
+}
+
+// checkThresholds returns the deployments targeted by rollingUpdate whose pods exceed one of
+// the thresholds in its spec.
+func (r *RollingUpdateReconciler) checkThresholds(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) ([]restartCondition, error) {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+	thresholds := rollingUpdate.Spec.Thresholds
+
+	maxPodAge := durationOrDefault(thresholds.MaxPodAge, 0)
+
+	deployments, err := r.listDeployments(ctx, rollingUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	exceeded := []restartCondition{}
+	for _, deployment := range deployments {
+		pods, err := r.listDeploymentPods(ctx, rollingUpdate, &deployment)
+		if err != nil {
+			return nil, err
+		}
+
+		message := podsExceedingThresholds(pods, maxPodAge, thresholds.MaxContainerRestarts, time.Now())
+		if message == "" {
+			continue
+		}
+		log.V(1).Info("Deployment exceeds restart threshold", "deployment", deployment.Name, "message", message)
+		exceeded = append(exceeded, restartCondition{
+			Deployment: deployment.Name,
+			Reason:     reasonThresholdExceeded,
+			Message:    message,
+		})
+	}
+
+	return exceeded, nil
+}
+
+// listDeploymentPods returns the pods selected by deployment that are not being deleted. The pods
+// are listed with the client of targetClient for rollingUpdate.
+func (r *RollingUpdateReconciler) listDeploymentPods(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, deployment *appsv1.Deployment) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector in Deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
+	}
+
+	c, err := r.targetClient(rollingUpdate)
+	if err != nil {
+		return nil, err
+	}
+	pods := &corev1.PodList{}
+	err = c.List(ctx, pods, client.InNamespace(deployment.Namespace), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of Deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
+	}
+
+	live := []corev1.Pod{}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil {
+			live = append(live, pod)
+		}
+	}
+	return live, nil
+}
+
+// podsExceedingThresholds returns a description of the first threshold exceeded by pods at
+// now, or an empty string if none is. A zero maxPodAge and a nil maxRestarts are not checked.
+func podsExceedingThresholds(pods []corev1.Pod, maxPodAge time.Duration, maxRestarts *int32, now time.Time) string {
+	for _, pod := range pods {
+		if maxPodAge > 0 {
+			if age := now.Sub(pod.CreationTimestamp.Time); age > maxPodAge {
+				return fmt.Sprintf("pod %s is %s old, more than %s", pod.Name, age.Truncate(time.Second), maxPodAge)
+			}
+		}
+		if maxRestarts != nil {
+			for _, status := range pod.Status.ContainerStatuses {
+				if status.RestartCount > *maxRestarts {
+					return fmt.Sprintf("container %s of pod %s restarted %d times, more than %d",
+						status.Name, pod.Name, status.RestartCount, *maxRestarts)
+				}
+			}
+		}
+	}
+	return ""
+}
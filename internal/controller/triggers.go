@@ -0,0 +1,364 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+const (
+	triggerKindSecret    = "Secret"
+	triggerKindConfigMap = "ConfigMap"
+)
+
+// triggerHashPrefix prefixes the keyed hashes recorded in the status. Recorded hashes without it
+// were computed by earlier versions of the operator without a key and cannot be compared.
+const triggerHashPrefix = "hmac-sha256:"
+
+// triggerState describes the objects watched by the triggers of a RollingUpdate as observed
+// during a reconcile.
+type triggerState struct {
+	// hashes holds the hashes of the objects selected by the triggers, keyed by "Kind/name".
+	hashes map[string]string
+
+	// changed lists the keys of the selected objects whose data changed.
+	changed []string
+
+	// workloadHashes holds the hashes of the objects consumed by each targeted deployment, if
+	// auto discovery is enabled.
+	workloadHashes map[string]map[string]string
+
+	// affected lists the targeted deployments whose consumed objects changed.
+	affected []string
+
+	// conditions lists the targeted deployments due for a restart because of their pods or
+	// metrics.
+	conditions []restartCondition
+}
+
+// checkTriggers hashes the data of the objects watched by the triggers of rollingUpdate and
+// compares the hashes with the ones recorded in status. Objects without a recorded hash are
+// not reported as changed. The hashes are keyed by the UID of rollingUpdate, so readers of the
+// status cannot guess the data of a Secret by hashing candidate values.
+func (r *RollingUpdateReconciler) checkTriggers(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) (*triggerState, error) {
+	state := &triggerState{}
+	triggers := rollingUpdate.Spec.Triggers
+	if triggers == nil {
+		return state, nil
+	}
+
+	if len(triggers.Secrets) > 0 || len(triggers.ConfigMaps) > 0 {
+		state.hashes = map[string]string{}
+	}
+	for _, selector := range triggers.Secrets {
+		secrets := &corev1.SecretList{}
+		if err := r.listSelected(ctx, rollingUpdate.Namespace, selector, &corev1.Secret{}, secrets); err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets.Items {
+			state.hashes[triggerKindSecret+"/"+secret.Name] = hashData(rollingUpdate.UID, secret.Data)
+		}
+	}
+	for _, selector := range triggers.ConfigMaps {
+		configMaps := &corev1.ConfigMapList{}
+		if err := r.listSelected(ctx, rollingUpdate.Namespace, selector, &corev1.ConfigMap{}, configMaps); err != nil {
+			return nil, err
+		}
+		for _, configMap := range configMaps.Items {
+			state.hashes[triggerKindConfigMap+"/"+configMap.Name] = hashData(rollingUpdate.UID, configMapData(&configMap))
+		}
+	}
+	state.changed = changedHashes(rollingUpdate.Status.TriggerHashes, state.hashes)
+
+	if triggers.AutoDiscover {
+		deployments, err := r.listDeployments(ctx, rollingUpdate)
+		if err != nil {
+			return nil, err
+		}
+
+		// Objects are often shared between deployments, so each is hashed only once.
+		objectHashes := map[string]string{}
+		state.workloadHashes = map[string]map[string]string{}
+		for _, deployment := range deployments {
+			hashes := map[string]string{}
+			for _, key := range consumedObjects(&deployment.Spec.Template.Spec) {
+				hash, ok := objectHashes[key]
+				if !ok {
+					hash, err = r.hashObject(ctx, rollingUpdate, key)
+					if err != nil {
+						return nil, err
+					}
+					objectHashes[key] = hash
+				}
+				if hash != "" {
+					hashes[key] = hash
+				}
+			}
+			state.workloadHashes[deployment.Name] = hashes
+
+			if changed := changedHashes(rollingUpdate.Status.WorkloadTriggerHashes[deployment.Name], hashes); len(changed) > 0 {
+				r.Log.V(1).Info("Consumed objects of deployment changed", "namespace", rollingUpdate.Namespace, "deployment", deployment.Name, "objects", changed)
+				state.affected = append(state.affected, deployment.Name)
+			}
+		}
+	}
+
+	return state, nil
+}
+
+// changedHashes returns the sorted keys present in both previous and current whose hashes
+// differ. Previous hashes without triggerHashPrefix are treated as not recorded.
+func changedHashes(previous map[string]string, current map[string]string) []string {
+	changed := []string{}
+	for key, hash := range current {
+		if old, ok := previous[key]; ok && strings.HasPrefix(old, triggerHashPrefix) && old != hash {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// hashObject returns the hash of the data of the Secret or ConfigMap identified by key in the
+// namespace of rollingUpdate, or an empty string if it does not exist.
+func (r *RollingUpdateReconciler) hashObject(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, key string) (string, error) {
+	namespace := rollingUpdate.Namespace
+	kind, name, _ := strings.Cut(key, "/")
+
+	var obj client.Object = &corev1.ConfigMap{}
+	if kind == triggerKindSecret {
+		obj = &corev1.Secret{}
+	}
+	err := r.apiReader().Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get %s %s/%s: %v", kind, namespace, name, err)
+	}
+
+	switch obj := obj.(type) {
+	case *corev1.Secret:
+		return hashData(rollingUpdate.UID, obj.Data), nil
+	case *corev1.ConfigMap:
+		return hashData(rollingUpdate.UID, configMapData(obj)), nil
+	}
+	return "", nil
+}
+
+// consumedObjects returns the sorted keys of the Secrets and ConfigMaps referenced by the
+// volumes, envFrom and env valueFrom of podSpec.
+func consumedObjects(podSpec *corev1.PodSpec) []string {
+	keys := map[string]bool{}
+	add := func(kind string, name string) {
+		if name != "" {
+			keys[kind+"/"+name] = true
+		}
+	}
+
+	for _, volume := range podSpec.Volumes {
+		if volume.Secret != nil {
+			add(triggerKindSecret, volume.Secret.SecretName)
+		}
+		if volume.ConfigMap != nil {
+			add(triggerKindConfigMap, volume.ConfigMap.Name)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					add(triggerKindSecret, source.Secret.Name)
+				}
+				if source.ConfigMap != nil {
+					add(triggerKindConfigMap, source.ConfigMap.Name)
+				}
+			}
+		}
+	}
+
+	containers := append([]corev1.Container{}, podSpec.InitContainers...)
+	containers = append(containers, podSpec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil {
+				add(triggerKindSecret, envFrom.SecretRef.Name)
+			}
+			if envFrom.ConfigMapRef != nil {
+				add(triggerKindConfigMap, envFrom.ConfigMapRef.Name)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				add(triggerKindSecret, env.ValueFrom.SecretKeyRef.Name)
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				add(triggerKindConfigMap, env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// listSelected fills list with the objects in namespace matched by selector. obj is used to
+// get a single object by name and is appended to list if found.
+func (r *RollingUpdateReconciler) listSelected(ctx context.Context, namespace string, selector flipperv1beta1.ObjectSelector, obj client.Object, list client.ObjectList) error {
+	if selector.Name == "" {
+		if len(selector.MatchLabels) == 0 {
+			return nil
+		}
+		if err := r.apiReader().List(ctx, list, client.InNamespace(namespace), client.MatchingLabels(selector.MatchLabels)); err != nil {
+			return fmt.Errorf("failed to list trigger objects in namespace %s: %v", namespace, err)
+		}
+		return nil
+	}
+
+	err := r.apiReader().Get(ctx, types.NamespacedName{Namespace: namespace, Name: selector.Name}, obj)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get trigger object %s/%s: %v", namespace, selector.Name, err)
+	}
+	switch list := list.(type) {
+	case *corev1.SecretList:
+		list.Items = append(list.Items, *obj.(*corev1.Secret))
+	case *corev1.ConfigMapList:
+		list.Items = append(list.Items, *obj.(*corev1.ConfigMap))
+	}
+	return nil
+}
+
+// rollingUpdatesForTrigger returns a function mapping a changed Secret or ConfigMap, of the given
+// kind, to the RollingUpdates in its namespace whose triggers select it. Only the metadata of
+// these objects is watched, so the mapped objects do not tell their kind.
+func (r *RollingUpdateReconciler) rollingUpdatesForTrigger(kind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		log := r.Log.WithValues("namespace", obj.GetNamespace(), "name", obj.GetName(), "kind", kind)
+
+		rollingUpdates := &flipperv1beta1.RollingUpdateList{}
+		if err := r.List(ctx, rollingUpdates, client.InNamespace(obj.GetNamespace())); err != nil {
+			log.Error(err, "Failed to list RollingUpdates for trigger object")
+			return nil
+		}
+
+		requests := []reconcile.Request{}
+		for _, rollingUpdate := range rollingUpdates.Items {
+			if rollingUpdate.Spec.Triggers == nil {
+				continue
+			}
+			if triggersOn(&rollingUpdate, kind, obj) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: rollingUpdate.Namespace, Name: rollingUpdate.Name},
+				})
+			}
+		}
+		return requests
+	}
+}
+
+// triggersOn reports whether the triggers of rollingUpdate watch obj of the given kind, either
+// because a selector matches it or because a targeted deployment was recorded consuming it.
+func triggersOn(rollingUpdate *flipperv1beta1.RollingUpdate, kind string, obj client.Object) bool {
+	triggers := rollingUpdate.Spec.Triggers
+	selectors := triggers.ConfigMaps
+	if kind == triggerKindSecret {
+		selectors = triggers.Secrets
+	}
+
+	for _, selector := range selectors {
+		if selectsObject(selector, obj) {
+			return true
+		}
+	}
+
+	// Objects not recorded yet only set the baseline, so there is no need to reconcile for them.
+	if triggers.AutoDiscover {
+		key := kind + "/" + obj.GetName()
+		for _, hashes := range rollingUpdate.Status.WorkloadTriggerHashes {
+			if _, ok := hashes[key]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectsObject reports whether selector matches obj.
+func selectsObject(selector flipperv1beta1.ObjectSelector, obj client.Object) bool {
+	if selector.Name != "" {
+		return selector.Name == obj.GetName()
+	}
+	if len(selector.MatchLabels) == 0 {
+		return false
+	}
+	return labels.SelectorFromSet(selector.MatchLabels).Matches(labels.Set(obj.GetLabels()))
+}
+
+// configMapData returns the data and binary data of configMap as a single map.
+func configMapData(configMap *corev1.ConfigMap) map[string][]byte {
+	data := map[string][]byte{}
+	for key, value := range configMap.Data {
+		data[key] = []byte(value)
+	}
+	for key, value := range configMap.BinaryData {
+		data[key] = value
+	}
+	return data
+}
+
+// hashData returns an HMAC-SHA256 of data keyed by uid, prefixed with triggerHashPrefix. It does
+// not depend on the order of the keys of data.
+func hashData(uid types.UID, data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := hmac.New(sha256.New, []byte(uid))
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(data[key])
+		hash.Write([]byte{0})
+	}
+	return triggerHashPrefix + hex.EncodeToString(hash.Sum(nil))
+}
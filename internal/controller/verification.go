@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+)
+
+// defaultVerificationTimeout is used when a verification check has no valid timeout.
+const defaultVerificationTimeout = 5 * time.Second
+
+// maxDrainedBodySize limits how much of the response body of a verification check is read. The
+// connection is not reused for larger bodies.
+const maxDrainedBodySize = 64 << 10
+
+// verifyWorkload runs the verification checks of rollingUpdate that apply to the deployment
+// named deployment. It returns a description of the first failed check together with the number
+// of retries allowed for it, or an empty string if all checks passed.
+func (r *RollingUpdateReconciler) verifyWorkload(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate, deployment string) (string, int32) {
+	log := r.Log.WithValues("namespace", rollingUpdate.Namespace, "name", rollingUpdate.Name)
+
+	for _, check := range rollingUpdate.Spec.Verification {
+		if check.Deployment != "" && check.Deployment != deployment {
+			continue
+		}
+
+		url := fmt.Sprintf("http://%s.%s.svc:%d%s", check.Service, rollingUpdate.Namespace, check.Port, check.Path)
+		timeout := durationOrDefault(check.Timeout, defaultVerificationTimeout)
+
+		log.V(1).Info("Verifying deployment", "deployment", deployment, "url", url)
+		if err := r.probeHTTP(ctx, url, int(check.ExpectedStatus), timeout); err != nil {
+			log.V(1).Info("Verification failed", "deployment", deployment, "url", url, "error", err.Error())
+			return fmt.Sprintf("verification of %s failed: %v", url, err), check.Retries
+		}
+	}
+
+	return "", 0
+}
+
+// probeHTTP sends a GET request to url and returns an error unless it is answered with
+// expectedStatus within timeout.
+func (r *RollingUpdateReconciler) probeHTTP(ctx context.Context, url string, expectedStatus int, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	// Drain the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxDrainedBodySize))
+
+	if response.StatusCode != expectedStatus {
+		return fmt.Errorf("expected status %d, got %d", expectedStatus, response.StatusCode)
+	}
+	return nil
+}
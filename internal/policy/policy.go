@@ -0,0 +1,160 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy implements the operator-level policy that restricts which workloads the
+// RollingUpdates of a namespace may restart.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Rules restrict the RollingUpdates of a namespace. Unset rules do not restrict them.
+type Rules struct {
+	// AllowedKinds lists the kinds of workloads RollingUpdates may restart. An empty list allows
+	// no kinds.
+	AllowedKinds []string `json:"allowedKinds,omitempty"`
+
+	// RequiredLabelPrefixes requires the selector of RollingUpdates to select workloads by a
+	// label whose key starts with one of these prefixes, through matchLabels or a matchExpressions
+	// requirement with the In or Exists operator.
+	RequiredLabelPrefixes []string `json:"requiredLabelPrefixes,omitempty"`
+
+	// MinInterval is the shortest interval RollingUpdates may restart their workloads at. It
+	// bounds the interval of their schedule, and the operator defers rollouts started for any
+	// other reason until MinInterval passed since the previous rollout.
+	MinInterval *metav1.Duration `json:"minInterval,omitempty"`
+
+	// MaxTargets is the largest number of workloads a single RollingUpdate may select.
+	MaxTargets *int32 `json:"maxTargets,omitempty"`
+}
+
+// Policy holds the rules of all namespaces.
+type Policy struct {
+	// Default holds the rules of all namespaces.
+	Default Rules `json:"default,omitempty"`
+
+	// Namespaces overrides the default rules per namespace. Rules that are unset for a namespace
+	// fall back to the default rules.
+	Namespaces map[string]Rules `json:"namespaces,omitempty"`
+}
+
+// Load reads the policy from the YAML file at path.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file %s: %v", path, err)
+	}
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file %s: %v", path, err)
+	}
+	return policy, nil
+}
+
+// For returns the rules of namespace. A nil policy has no rules.
+func (p *Policy) For(namespace string) Rules {
+	if p == nil {
+		return Rules{}
+	}
+	rules := p.Default
+	override, ok := p.Namespaces[namespace]
+	if !ok {
+		return rules
+	}
+	if override.AllowedKinds != nil {
+		rules.AllowedKinds = override.AllowedKinds
+	}
+	if override.RequiredLabelPrefixes != nil {
+		rules.RequiredLabelPrefixes = override.RequiredLabelPrefixes
+	}
+	if override.MinInterval != nil {
+		rules.MinInterval = override.MinInterval
+	}
+	if override.MaxTargets != nil {
+		rules.MaxTargets = override.MaxTargets
+	}
+	return rules
+}
+
+// Validate returns the violations of the rules by a RollingUpdate restarting workloads of kind,
+// selected by selector, at interval.
+func (r Rules) Validate(kind string, selector *metav1.LabelSelector, interval time.Duration) []string {
+	var violations []string
+	if r.AllowedKinds != nil && !slices.Contains(r.AllowedKinds, kind) {
+		allowed := "none"
+		if len(r.AllowedKinds) > 0 {
+			allowed = strings.Join(r.AllowedKinds, ", ")
+		}
+		violations = append(violations, fmt.Sprintf("target kind %s is not allowed, allowed kinds are %s", kind, allowed))
+	}
+	if len(r.RequiredLabelPrefixes) > 0 && !r.selectsByRequiredLabel(selector) {
+		violations = append(violations, fmt.Sprintf("selector must select workloads by a label with one of the prefixes %s",
+			strings.Join(r.RequiredLabelPrefixes, ", ")))
+	}
+	if r.MinInterval != nil && interval < r.MinInterval.Duration {
+		violations = append(violations, fmt.Sprintf("interval %s is shorter than the minimum interval %s",
+			interval, r.MinInterval.Duration))
+	}
+	return violations
+}
+
+// ValidateTargets returns the violation of the rules by a RollingUpdate selecting count
+// workloads, if any.
+func (r Rules) ValidateTargets(count int) []string {
+	if r.MaxTargets != nil && count > int(*r.MaxTargets) {
+		return []string{fmt.Sprintf("%d workloads are selected, at most %d are allowed", count, *r.MaxTargets)}
+	}
+	return nil
+}
+
+// selectsByRequiredLabel reports whether selector only selects workloads carrying a label with
+// one of the required prefixes.
+func (r Rules) selectsByRequiredLabel(selector *metav1.LabelSelector) bool {
+	if selector == nil {
+		return false
+	}
+	for key := range selector.MatchLabels {
+		if r.hasRequiredPrefix(key) {
+			return true
+		}
+	}
+	for _, requirement := range selector.MatchExpressions {
+		if requirement.Operator != metav1.LabelSelectorOpIn && requirement.Operator != metav1.LabelSelectorOpExists {
+			continue
+		}
+		if r.hasRequiredPrefix(requirement.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rules) hasRequiredPrefix(key string) bool {
+	for _, prefix := range r.RequiredLabelPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
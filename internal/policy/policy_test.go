@@ -0,0 +1,98 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Policy", func() {
+	maxTargets := int32(2)
+	policy := &Policy{
+		Default: Rules{
+			AllowedKinds:          []string{"Deployment"},
+			RequiredLabelPrefixes: []string{"team.example.com/"},
+			MinInterval:           &metav1.Duration{Duration: time.Hour},
+		},
+		Namespaces: map[string]Rules{
+			"payments": {MaxTargets: &maxTargets},
+			"frozen":   {AllowedKinds: []string{}},
+		},
+	}
+	teamSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"team.example.com/owner": "payments"}}
+
+	It("should merge the namespace rules into the default rules", func() {
+		rules := policy.For("payments")
+		Expect(rules.AllowedKinds).To(Equal([]string{"Deployment"}))
+		Expect(rules.MinInterval.Duration).To(Equal(time.Hour))
+		Expect(*rules.MaxTargets).To(Equal(int32(2)))
+
+		Expect(policy.For("default").MaxTargets).To(BeNil())
+		Expect((*Policy)(nil).For("default")).To(Equal(Rules{}))
+	})
+
+	It("should admit RollingUpdates complying with the rules", func() {
+		Expect(policy.For("payments").Validate("Deployment", teamSelector, 2*time.Hour)).To(BeEmpty())
+		Expect(policy.For("payments").ValidateTargets(2)).To(BeEmpty())
+		Expect(Rules{}.Validate("Deployment", nil, time.Second)).To(BeEmpty())
+	})
+
+	It("should report the violated rules", func() {
+		rules := policy.For("payments")
+		Expect(rules.Validate("Deployment", teamSelector, time.Minute)).To(ConsistOf(
+			"interval 1m0s is shorter than the minimum interval 1h0m0s"))
+		Expect(rules.Validate("Deployment", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}}, time.Hour)).To(ConsistOf(
+			"selector must select workloads by a label with one of the prefixes team.example.com/"))
+		Expect(rules.Validate("Deployment", nil, time.Hour)).To(HaveLen(1))
+		Expect(rules.ValidateTargets(3)).To(ConsistOf("3 workloads are selected, at most 2 are allowed"))
+
+		Expect(policy.For("frozen").Validate("Deployment", teamSelector, time.Hour)).To(ConsistOf(
+			"target kind Deployment is not allowed, allowed kinds are none"))
+	})
+
+	It("should only accept selector requirements that require the label", func() {
+		rules := policy.For("default")
+		selector := func(operator metav1.LabelSelectorOperator) *metav1.LabelSelector {
+			return &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "team.example.com/owner", Operator: operator, Values: []string{"payments"}},
+			}}
+		}
+		Expect(rules.Validate("Deployment", selector(metav1.LabelSelectorOpIn), time.Hour)).To(BeEmpty())
+		Expect(rules.Validate("Deployment", selector(metav1.LabelSelectorOpExists), time.Hour)).To(BeEmpty())
+		Expect(rules.Validate("Deployment", selector(metav1.LabelSelectorOpNotIn), time.Hour)).To(HaveLen(1))
+	})
+
+	It("should load the policy from a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "policy.yaml")
+		Expect(os.WriteFile(path, []byte("default:\n  minInterval: 1h\nnamespaces:\n  payments:\n    maxTargets: 2\n"), 0o600)).To(Succeed())
+
+		loaded, err := Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded.Default.MinInterval.Duration).To(Equal(time.Hour))
+		Expect(*loaded.Namespaces["payments"].MaxTargets).To(Equal(int32(2)))
+
+		Expect(os.WriteFile(path, []byte("default:\n  minIntervall: 1h\n"), 0o600)).To(Succeed())
+		_, err = Load(path)
+		Expect(err).To(MatchError(ContainSubstring("minIntervall")))
+	})
+})
@@ -0,0 +1,49 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package targets selects the workloads restarted by RollingUpdates. It is shared by the operator
+// and the kubectl plugin, so both select the same workloads.
+package targets
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// List returns the deployments in namespace selected by the selector of a RollingUpdate. A nil
+// selector selects all deployments.
+func List(ctx context.Context, c client.Reader, namespace string, selector *metav1.LabelSelector) ([]appsv1.Deployment, error) {
+	matching := labels.Everything()
+	if selector != nil {
+		var err error
+		matching, err = metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector: %v", err)
+		}
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	err := c.List(ctx, deployments, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: matching})
+	if err != nil {
+		return nil, err
+	}
+	return deployments.Items, nil
+}
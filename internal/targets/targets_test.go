@@ -0,0 +1,80 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package targets
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("List", func() {
+	ctx := context.Background()
+
+	deployment := func(namespace, name string, labels map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+	}
+	names := func(deployments []appsv1.Deployment) []string {
+		result := []string{}
+		for _, deployment := range deployments {
+			result = append(result, deployment.Name)
+		}
+		return result
+	}
+
+	var c client.Client
+
+	BeforeEach(func() {
+		c = fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+			deployment("default", "nginx", map[string]string{"app": "nginx", "tier": "web"}),
+			deployment("default", "mysql", map[string]string{"app": "mysql"}),
+			deployment("other", "nginx", map[string]string{"app": "nginx"}),
+		).Build()
+	})
+
+	It("should select the deployments in the namespace matching the selector", func() {
+		deployments, err := List(ctx, c, "default", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(deployments)).To(ConsistOf("nginx"))
+		Expect(deployments[0].Namespace).To(Equal("default"))
+
+		deployments, err = List(ctx, c, "default", &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpDoesNotExist},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(deployments)).To(ConsistOf("mysql"))
+	})
+
+	It("should select all deployments in the namespace without a selector", func() {
+		deployments, err := List(ctx, c, "default", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(deployments)).To(ConsistOf("nginx", "mysql"))
+	})
+
+	It("should reject an invalid selector", func() {
+		_, err := List(ctx, c, "default", &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: "Matches"},
+		}})
+		Expect(err).To(MatchError(ContainSubstring("invalid selector")))
+	})
+})
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+	"github.com/sigsegv1989/flipper-operator/internal/config"
+	"github.com/sigsegv1989/flipper-operator/internal/targets"
+)
+
+// SetupRollingUpdateWebhookWithManager registers the webhooks of RollingUpdate with mgr. This
+// includes the conversion webhook, since v1beta1 is the hub of the RollingUpdate versions, and
+// the validating webhook enforcing the current policy and metricsURLs of operatorConfig. Without
+// a policy, all RollingUpdates without a metrics trigger are admitted.
+func SetupRollingUpdateWebhookWithManager(mgr ctrl.Manager, operatorConfig *config.Store) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&flipperv1beta1.RollingUpdate{}).
+		WithValidator(&RollingUpdateCustomValidator{config: operatorConfig, reader: mgr.GetAPIReader()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-flipper-example-com-v1beta1-rollingupdate,mutating=false,failurePolicy=fail,sideEffects=None,groups=flipper.example.com,resources=rollingupdates,verbs=create;update,versions=v1beta1,name=vrollingupdate-v1beta1.kb.io,admissionReviewVersions=v1
+
+// RollingUpdateCustomValidator rejects RollingUpdates that violate the policy of the operator,
+// or whose metrics trigger queries a URL the operator does not allow.
+type RollingUpdateCustomValidator struct {
+	// config holds the policy and the default interval, which may be reloaded at any time.
+	config *config.Store
+	// reader lists the deployments selected by a RollingUpdate, to check their number.
+	reader client.Reader
+}
+
+var _ admission.CustomValidator = &RollingUpdateCustomValidator{}
+
+// ValidateCreate implements admission.CustomValidator.
+func (v *RollingUpdateCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	rollingUpdate, ok := obj.(*flipperv1beta1.RollingUpdate)
+	if !ok {
+		return nil, fmt.Errorf("expected a RollingUpdate but got a %T", obj)
+	}
+	return v.validate(ctx, rollingUpdate)
+}
+
+// ValidateUpdate implements admission.CustomValidator. Updates that keep the spec, such as
+// changes of the finalizers or the trigger annotation, are always admitted, so RollingUpdates
+// created before the policy was tightened can still be triggered and deleted.
+func (v *RollingUpdateCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldRollingUpdate, ok := oldObj.(*flipperv1beta1.RollingUpdate)
+	if !ok {
+		return nil, fmt.Errorf("expected a RollingUpdate but got a %T", oldObj)
+	}
+	rollingUpdate, ok := newObj.(*flipperv1beta1.RollingUpdate)
+	if !ok {
+		return nil, fmt.Errorf("expected a RollingUpdate but got a %T", newObj)
+	}
+	if !rollingUpdate.DeletionTimestamp.IsZero() || equality.Semantic.DeepEqual(oldRollingUpdate.Spec, rollingUpdate.Spec) {
+		return nil, nil
+	}
+	return v.validate(ctx, rollingUpdate)
+}
+
+// ValidateDelete implements admission.CustomValidator. Deletes are not validated.
+func (v *RollingUpdateCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *RollingUpdateCustomValidator) validate(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) (admission.Warnings, error) {
+	// Rollouts are scheduled by dividing by the interval, which must therefore be positive.
+	if interval := rollingUpdate.Spec.Schedule.Interval; interval != nil && interval.Duration <= 0 {
+		return nil, apierrors.NewInvalid(flipperv1beta1.GroupVersion.WithKind("RollingUpdate").GroupKind(), rollingUpdate.Name, field.ErrorList{
+			field.Invalid(field.NewPath("spec", "schedule", "interval"), interval.Duration.String(), "must be positive"),
+		})
+	}
+
+	operatorConfig := v.config.Get()
+	violations := []string{}
+	if metrics := rollingUpdate.Spec.Metrics; metrics != nil && !operatorConfig.MetricsURLAllowed(metrics.URL) {
+		violations = append(violations, fmt.Sprintf("metrics URL %q is not one of the metricsURLs of the operator", metrics.URL))
+	}
+
+	var warnings admission.Warnings
+	if operatorConfig.Policy != nil {
+		rules := operatorConfig.Policy.For(rollingUpdate.Namespace)
+
+		// The interval defaults to the interval of the operator config, see ScheduleSpec.
+		interval := operatorConfig.DefaultInterval.Duration
+		if rollingUpdate.Spec.Schedule.Interval != nil {
+			interval = rollingUpdate.Spec.Schedule.Interval.Duration
+		}
+		violations = append(violations, rules.Validate(string(flipperv1beta1.TargetKindDeployment), rollingUpdate.Spec.Selector, interval)...)
+
+		if rules.MaxTargets != nil {
+			count, err := v.countTargets(ctx, rollingUpdate)
+			if err != nil {
+				// The operator checks the number of targets again when reconciling the RollingUpdate.
+				warnings = append(warnings, fmt.Sprintf("the number of selected deployments was not checked: %v", err))
+			} else {
+				violations = append(violations, rules.ValidateTargets(count)...)
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return warnings, apierrors.NewForbidden(flipperv1beta1.GroupVersion.WithResource("rollingupdates").GroupResource(), rollingUpdate.Name,
+			errors.New("the policy of the operator is violated: "+strings.Join(violations, "; ")))
+	}
+	return warnings, nil
+}
+
+// countTargets returns the number of deployments selected by rollingUpdate, found the way the
+// operator finds its targets.
+func (v *RollingUpdateCustomValidator) countTargets(ctx context.Context, rollingUpdate *flipperv1beta1.RollingUpdate) (int, error) {
+	deployments, err := targets.List(ctx, v.reader, rollingUpdate.Namespace, rollingUpdate.Spec.Selector)
+	if err != nil {
+		return 0, err
+	}
+	return len(deployments), nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2024 Abhijeet Rokade.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	flipperv1beta1 "github.com/sigsegv1989/flipper-operator/api/v1beta1"
+	"github.com/sigsegv1989/flipper-operator/internal/config"
+	"github.com/sigsegv1989/flipper-operator/internal/policy"
+)
+
+var _ = Describe("RollingUpdate validating webhook", func() {
+	ctx := context.Background()
+	maxTargets := int32(1)
+
+	deployment := func(name string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{"team.example.com/owner": "payments"},
+		}}
+	}
+	newRollingUpdate := func() *flipperv1beta1.RollingUpdate {
+		return &flipperv1beta1.RollingUpdate{
+			ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "default"},
+			Spec: flipperv1beta1.RollingUpdateSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team.example.com/owner": "payments"}},
+				Schedule: flipperv1beta1.ScheduleSpec{Interval: &metav1.Duration{Duration: 2 * time.Hour}},
+			},
+		}
+	}
+
+	var validator *RollingUpdateCustomValidator
+
+	BeforeEach(func() {
+		validator = &RollingUpdateCustomValidator{
+			config: config.NewStore(&config.FlipperConfig{
+				Policy: &policy.Policy{Default: policy.Rules{
+					RequiredLabelPrefixes: []string{"team.example.com/"},
+					MinInterval:           &metav1.Duration{Duration: time.Hour},
+					MaxTargets:            &maxTargets,
+				}},
+			}),
+			reader: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment("api")).Build(),
+		}
+	})
+
+	It("should admit RollingUpdates complying with the policy", func() {
+		warnings, err := validator.ValidateCreate(ctx, newRollingUpdate())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(BeEmpty())
+
+		// Without an interval, the default interval of 24h applies.
+		rollingUpdate := newRollingUpdate()
+		rollingUpdate.Spec.Schedule.Interval = nil
+		_, err = validator.ValidateCreate(ctx, rollingUpdate)
+		Expect(err).NotTo(HaveOccurred())
+
+		validator.config = nil
+		rollingUpdate.Spec.Selector = nil
+		_, err = validator.ValidateCreate(ctx, rollingUpdate)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject RollingUpdates violating the policy", func() {
+		rollingUpdate := newRollingUpdate()
+		rollingUpdate.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}}
+		rollingUpdate.Spec.Schedule.Interval = &metav1.Duration{Duration: time.Minute}
+		_, err := validator.ValidateCreate(ctx, rollingUpdate)
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("selector must select workloads by a label with one of the prefixes team.example.com/"))
+		Expect(err.Error()).To(ContainSubstring("interval 1m0s is shorter than the minimum interval 1h0m0s"))
+	})
+
+	It("should reject intervals that are not positive", func() {
+		validator.config = config.NewStore(&config.FlipperConfig{})
+		for _, interval := range []time.Duration{0, -time.Hour} {
+			rollingUpdate := newRollingUpdate()
+			rollingUpdate.Spec.Schedule.Interval = &metav1.Duration{Duration: interval}
+			_, err := validator.ValidateCreate(ctx, rollingUpdate)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.schedule.interval: Invalid value"))
+		}
+	})
+
+	It("should apply the default interval of the operator config", func() {
+		operatorConfig := validator.config.Get()
+		validator.config.Set(&config.FlipperConfig{
+			Policy:          operatorConfig.Policy,
+			DefaultInterval: &metav1.Duration{Duration: time.Minute},
+		})
+
+		rollingUpdate := newRollingUpdate()
+		rollingUpdate.Spec.Schedule.Interval = nil
+		_, err := validator.ValidateCreate(ctx, rollingUpdate)
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("interval 1m0s is shorter than the minimum interval 1h0m0s"))
+	})
+
+	It("should reject RollingUpdates selecting too many deployments", func() {
+		validator.reader = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment("api"), deployment("worker")).Build()
+
+		_, err := validator.ValidateCreate(ctx, newRollingUpdate())
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("2 workloads are selected, at most 1 are allowed"))
+	})
+
+	It("should only admit metrics triggers querying an allowed URL", func() {
+		rollingUpdate := newRollingUpdate()
+		rollingUpdate.Spec.Metrics = &flipperv1beta1.MetricsTrigger{URL: "http://prometheus.monitoring.svc:9090", Query: "up == 0"}
+		_, err := validator.ValidateCreate(ctx, rollingUpdate)
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`metrics URL "http://prometheus.monitoring.svc:9090" is not one of the metricsURLs of the operator`))
+
+		// Without a policy, the metrics URL is still checked.
+		validator.config.Set(&config.FlipperConfig{MetricsURLs: []string{"http://prometheus.monitoring.svc:9090"}})
+		_, err = validator.ValidateCreate(ctx, rollingUpdate)
+		Expect(err).NotTo(HaveOccurred())
+
+		rollingUpdate.Spec.Metrics.URL = "http://kubernetes.default.svc"
+		_, err = validator.ValidateCreate(ctx, rollingUpdate)
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+	})
+
+	It("should only validate updates changing the spec", func() {
+		oldRollingUpdate := newRollingUpdate()
+		oldRollingUpdate.Spec.Schedule.Interval = &metav1.Duration{Duration: time.Minute}
+
+		rollingUpdate := oldRollingUpdate.DeepCopy()
+		rollingUpdate.Finalizers = nil
+		_, err := validator.ValidateUpdate(ctx, oldRollingUpdate, rollingUpdate)
+		Expect(err).NotTo(HaveOccurred())
+
+		rollingUpdate.Spec.Suspend = true
+		_, err = validator.ValidateUpdate(ctx, oldRollingUpdate, rollingUpdate)
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+	})
+})
@@ -233,11 +233,12 @@ var _ = Describe("controller", Ordered, func() {
 			deployment, err = clientset.AppsV1().Deployments(namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
 			Expect(err).NotTo(HaveOccurred())
 
-			// Check if the annotation exists and is not empty
-			restartedAtExists := deployment.Annotations["kubectl.kubernetes.io/restartedAt"] != ""
-			restartedBy := deployment.Annotations["kubectl.kubernetes.io/restartedBy"] == "flipper-operator"
-			restartedByCRExists := deployment.Annotations["flipper.example.com/restartedByCR"] != ""
-			restartedByCRDKind := deployment.Annotations["flipper.example.com/restartedByCRDKind"] == "rollingupdate"
+			// Check if the pod template annotation exists and is not empty
+			annotations := deployment.Spec.Template.Annotations
+			restartedAtExists := annotations["kubectl.kubernetes.io/restartedAt"] != ""
+			restartedBy := annotations["kubectl.kubernetes.io/restartedBy"] == "flipper-operator"
+			restartedByCRExists := annotations["flipper.example.com/restartedByCR"] != ""
+			restartedByCRDKind := annotations["flipper.example.com/restartedByCRDKind"] == "rollingupdate"
 
 			return restartedAtExists && restartedBy && restartedByCRExists && restartedByCRDKind
 		}, 5*time.Minute, 5*time.Second).Should(BeTrue())